@@ -1,12 +1,24 @@
 package main
 
 import (
-	"github.com/joho/godotenv"
-	"log"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
 	"quest_service/configs"
 	"quest_service/internal/handler"
+	"quest_service/internal/logger"
+	"quest_service/internal/metrics"
 	"quest_service/internal/repository"
+	"quest_service/internal/server"
 	"quest_service/internal/service"
+	"quest_service/internal/tracing"
+	"quest_service/schema"
+	"syscall"
+	"time"
 )
 
 //	@title		Quest-Service
@@ -14,29 +26,130 @@ import (
 
 //	@BasePath	/api
 
+//	@securityDefinitions.apikey	ApiKeyAuth
+//	@in							header
+//	@name						Authorization
+//	@description				Access-токен в формате "Bearer <token>"
+
 func main() {
-	cfg, err := configs.GetConfig()
+	cfg, args, err := configs.Load(os.Args[1:])
+	if err != nil {
+		fatal("Ошибка конфигурации", err)
+	}
+	log, err := logger.New(os.Stdout, cfg.Logging.Level, cfg.Logging.Format)
+	if err != nil {
+		fatal("Ошибка конфигурации", err)
+	}
+	slog.SetDefault(log)
+	slog.Info("Действующая конфигурация", "config", cfg.Redacted())
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.SampleRatio)
 	if err != nil {
-		log.Fatalln(err)
+		fatal("Ошибка при настройке трассировки", err)
 	}
 
 	db, err := repository.NewPostgresDB(cfg)
 	if err != nil {
-		log.Fatalf("Ошибка при инициализации БД: %s", err.Error())
+		fatal("Ошибка при инициализации БД", err)
 	}
 
-	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
-	handlers := handler.NewHandler(services)
+	migrator, err := repository.NewMigrator(db, schema.Migrations)
+	if err != nil {
+		fatal("Ошибка при чтении миграций", err)
+	}
+	// Подкоманда migrate: quest_service migrate up|down|status|goto N
+	if len(args) > 0 && args[0] == "migrate" {
+		if err = runMigrate(migrator, args[1:]); err != nil {
+			fatal("Ошибка миграции", err)
+		}
+		return
+	}
+	if cfg.Features.AutoMigrate {
+		if err = migrator.Up(); err != nil {
+			fatal("Ошибка при применении миграций", err)
+		}
+	}
+
+	metrics.RegisterDBStats(db.DB, cfg.DB.Name)
+
+	repos := repository.NewRepository(db, migrator, cfg)
+	services := service.NewService(repos, cfg)
+	handlers := handler.NewHandler(services, cfg)
 
-	handlers.InitRoutes(cfg.AppPort)
+	background := newWorkers()
+	if cfg.Retention.Enabled {
+		background.Go(func(ctx context.Context) {
+			runRetention(ctx, services.Trash, cfg.Retention)
+		})
+	}
+
+	srv := server.New(cfg, handlers.InitRoutes())
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.Run()
+	}()
+	scheme := "http"
+	if cfg.HTTP.TLS.Enabled() {
+		scheme = "https"
+	}
+	slog.Info("Сервис запущен", "port", cfg.HTTP.Port, "swagger", scheme+"://127.0.0.1:"+cfg.HTTP.Port+"/api/swagger/")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	failed := false
+	select {
+	case <-ctx.Done():
+	case err = <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Ошибка HTTP-сервера", "error", err)
+			failed = true
+		}
+	}
+	// Повторный сигнал завершает процесс сразу
+	stop()
+	slog.Info("Завершение работы")
+
+	// /readyz начинает отвечать 503; пока балансировщик это замечает, запросы ещё принимаются.
+	// Упавший сервер запросов уже не принимает, ждать нечего
+	if !failed {
+		services.Health.StartDraining()
+		time.Sleep(cfg.HTTP.ShutdownDelay)
+	}
+
+	shutdown(srv, background, db, shutdownTracing, cfg.HTTP.ShutdownTimeout)
+	// Ненулевой код сообщает супервизору, что сервис упал, а не был остановлен
+	if failed {
+		os.Exit(1)
+	}
 }
 
-func init() {
-	log.SetFlags(log.Ldate | log.Ltime | log.LUTC | log.Lshortfile)
+// shutdown останавливает сервис по порядку: дожидается начатых запросов, затем
+// фоновых задач, закрывает пул соединений с БД и отправляет оставшиеся spans
+func shutdown(srv *server.Server, background *workers, db io.Closer, shutdownTracing func(context.Context) error, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalln(err)
+	if err := srv.Shutdown(ctx); err != nil {
+		// Срок истёк: обрываем оставшиеся запросы, их запросы к БД отменяются вместе с контекстом
+		slog.Warn("Не все запросы завершились в отведённое время", "timeout", timeout, "error", err)
+		srv.Close()
 	}
+
+	if err := background.Stop(ctx); err != nil {
+		slog.Warn("Фоновые задачи не остановились в отведённое время", "error", err)
+	}
+
+	if err := db.Close(); err != nil {
+		slog.Error("Ошибка при закрытии соединений с БД", "error", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Ошибка при отправке трасс", "error", err)
+	}
+	slog.Info("Сервис остановлен")
+}
+
+// fatal пишет ошибку в журнал и завершает процесс
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
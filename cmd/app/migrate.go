@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"quest_service/internal/repository"
+	"strconv"
+)
+
+const migrateUsage = `usage: quest_service migrate <command>
+
+commands:
+  up        apply all pending migrations
+  down      roll back the last applied migration
+  status    print the current schema version and pending migrations
+  goto N    migrate up or down to version N
+  force N   set version N without running migrations (for manually applied schemas)
+
+A database created by hand from schema/000001_init.up.sql has no schema_migrations
+table. Mark it once with "migrate force 1", then run "migrate up".`
+
+// runMigrate выполняет подкоманду migrate и завершает работу
+func runMigrate(migrator *repository.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		return migrator.Up()
+	case "down":
+		return migrator.Down()
+	case "status":
+		return printMigrationStatus(migrator)
+	case "goto", "force":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if args[0] == "goto" {
+			return migrator.Goto(version)
+		}
+		return migrator.Force(version)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func printMigrationStatus(migrator *repository.Migrator) error {
+	status, err := migrator.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	dirty := ""
+	if status.Dirty {
+		dirty = " (dirty)"
+	}
+	fmt.Printf("Текущая версия схемы: %d%s, последняя: %d\n", status.Version, dirty, status.Latest)
+	for _, m := range status.Pending {
+		fmt.Printf("Ожидает применения: %06d_%s\n", m.Version, m.Name)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"quest_service/configs"
+	"quest_service/internal/service"
+	"time"
+)
+
+// runRetention раз в cfg.Interval очищает корзину от записей, удалённых раньше cfg.MaxAge
+func runRetention(ctx context.Context, trash service.Trash, cfg configs.RetentionConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		stats, err := trash.PurgeExpired(ctx, start.Add(-cfg.MaxAge))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.Error("Ошибка очистки корзины", "error", err)
+		case stats.Quests+stats.Tasks+stats.Anonymized > 0:
+			slog.Info("Корзина очищена",
+				"quests", stats.Quests, "tasks", stats.Tasks, "anonymized", stats.Anonymized,
+				"duration", time.Since(start))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// workers — фоновые задачи сервиса. Останавливаются после HTTP-сервера и до закрытия БД
+type workers struct {
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func newWorkers() *workers {
+	ctx, stop := context.WithCancel(context.Background())
+	return &workers{ctx: ctx, stop: stop}
+}
+
+// Go запускает задачу. fn должна вернуться вскоре после отмены контекста
+func (w *workers) Go(fn func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		fn(w.ctx)
+	}()
+}
+
+// Stop отменяет контекст задач и ждёт их завершения, но не дольше ctx
+func (w *workers) Stop(ctx context.Context) error {
+	w.stop()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,60 +1,265 @@
 package configs
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"github.com/joho/godotenv"
+	"io/fs"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	AppPort   string
-	DBHost    string
-	DBPort    string
-	DBUser    string
-	DBPass    string
-	DBName    string
-	DBSSLMode string
+	HTTP      HTTPConfig
+	DB        DBConfig
+	Auth      AuthConfig
+	Logging   LoggingConfig
+	Tracing   TracingConfig
+	Retention RetentionConfig
+	Features  FeaturesConfig
 }
 
-func GetConfig() (Config, error) {
-	AppPort := os.Getenv("APP_PORT")
-	if AppPort == "" {
-		AppPort = "8080"
+type HTTPConfig struct {
+	Port            string
+	RequestTimeout  time.Duration
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	ShutdownDelay   time.Duration
+	TLS             TLSConfig
+}
+
+// TLSConfig — сертификат и ключ. Без них сервер принимает обычный HTTP
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// DBConfig — подключение к PostgreSQL: строка DSN или отдельные параметры
+type DBConfig struct {
+	DSN      string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	QueryTimeout    time.Duration
+}
+
+// ConnString возвращает строку подключения для lib/pq
+func (c DBConfig) ConnString() string {
+	if c.DSN != "" {
+		return c.DSN
 	}
-	DBHost := os.Getenv("DB_HOST")
-	if DBHost == "" {
-		return Config{}, fmt.Errorf("DB_HOST is not set")
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("host='%s' port='%s' user='%s' password='%s' dbname='%s' sslmode='%s'",
+		quote.Replace(c.Host), quote.Replace(c.Port), quote.Replace(c.User),
+		quote.Replace(c.Password), quote.Replace(c.Name), quote.Replace(c.SSLMode))
+}
+
+type AuthConfig struct {
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type LoggingConfig struct {
+	Level  string
+	Format string
+}
+
+type TracingConfig struct {
+	Exporter    string
+	SampleRatio float64
+}
+
+// RetentionConfig — фоновая очистка корзины от давно удалённых квестов и заданий
+type RetentionConfig struct {
+	Enabled bool
+	// MaxAge — сколько удалённая запись хранится в корзине
+	MaxAge time.Duration
+	// Interval — период запуска очистки
+	Interval time.Duration
+}
+
+// FeaturesConfig — включаемые возможности сервиса
+type FeaturesConfig struct {
+	// AutoMigrate применяет миграции при запуске
+	AutoMigrate bool
+	// TestData открывает маршрут генерации тестовых квестов
+	TestData bool
+	// Metrics открывает маршрут /metrics
+	Metrics bool
+}
+
+// Load собирает конфигурацию: значения по умолчанию, файл, переменные окружения
+// (в том числе из .env), флаги командной строки — каждый следующий источник важнее.
+// Возвращает аргументы, оставшиеся после флагов.
+func Load(args []string) (Config, []string, error) {
+	var cfg Config
+	settings := newSettings(&cfg)
+
+	flagSet := flag.NewFlagSet("quest_service", flag.ExitOnError)
+	configFile := flagSet.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file (env CONFIG_FILE)")
+	flagValues := map[string]string{}
+	for _, s := range settings {
+		s := s
+		usage := fmt.Sprintf("%s (env %s, default %q)", s.key, s.env, s.def)
+		setFlag := func(value string) error {
+			flagValues[s.key] = value
+			return nil
+		}
+		if s.isBool {
+			flagSet.BoolFunc(s.flagName(), usage, setFlag)
+		} else {
+			flagSet.Func(s.flagName(), usage, setFlag)
+		}
 	}
-	DBPort := os.Getenv("DB_PORT")
-	if DBPort == "" {
-		return Config{}, fmt.Errorf("DB_PORT is not set")
+	// Прежнее имя флага, оставлено для совместимости
+	flagSet.BoolFunc("auto-migrate", "alias for -features-auto-migrate", func(value string) error {
+		flagValues["features.auto_migrate"] = value
+		return nil
+	})
+	if err := flagSet.Parse(args); err != nil {
+		return Config{}, nil, err
 	}
-	DBUser := os.Getenv("DB_USER")
-	if DBUser == "" {
-		return Config{}, fmt.Errorf("DB_USER is not set")
+
+	// .env необязателен: в контейнере переменные задаются окружением
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil, fmt.Errorf(".env: %w", err)
+	}
+
+	fileValues := map[string]string{}
+	if *configFile != "" {
+		var err error
+		fileValues, err = readFile(*configFile)
+		if err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	var errs []error
+	known := map[string]bool{}
+	for _, s := range settings {
+		known[s.key] = true
+
+		value := s.def
+		if v, ok := fileValues[s.key]; ok {
+			value = v
+		}
+		if v, ok := os.LookupEnv(s.env); ok && v != "" {
+			value = v
+		}
+		if v, ok := flagValues[s.key]; ok {
+			value = v
+		}
+		if err := s.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.key, err))
+		}
 	}
-	DBPass := os.Getenv("DB_PASS")
-	if DBPass == "" {
-		return Config{}, fmt.Errorf("DB_PASS is not set")
+	for key := range fileValues {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("%s: unknown setting in %s", key, *configFile))
+		}
 	}
-	DBName := os.Getenv("DB_NAME")
-	if DBName == "" {
-		return Config{}, fmt.Errorf("DB_NAME is not set")
+	if len(errs) == 0 {
+		errs = cfg.validate()
 	}
-	DBSSLMode := os.Getenv("DB_SSL_MODE")
-	if DBSSLMode == "" {
-		return Config{}, fmt.Errorf("DB_SSL_MODE is not set")
+	if len(errs) > 0 {
+		return Config{}, nil, errors.Join(errs...)
 	}
 
-	cfg := Config{
-		AppPort:   AppPort,
-		DBHost:    DBHost,
-		DBPort:    DBPort,
-		DBUser:    DBUser,
-		DBPass:    DBPass,
-		DBName:    DBName,
-		DBSSLMode: DBSSLMode,
+	return cfg, flagSet.Args(), nil
+}
+
+// validate проверяет значения целиком и возвращает все найденные ошибки
+func (c Config) validate() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
 	}
 
-	return cfg, nil
+	port, err := strconv.Atoi(c.HTTP.Port)
+	check(err == nil && port > 0 && port < 65536, "http.port must be a TCP port number")
+	check(c.HTTP.RequestTimeout > 0, "http.request_timeout must be positive")
+	check(c.HTTP.ReadTimeout > 0, "http.read_timeout must be positive")
+	// Иначе ответ 504 по истечении request_timeout не успеет уйти клиенту
+	check(c.HTTP.WriteTimeout > c.HTTP.RequestTimeout, "http.write_timeout must be greater than http.request_timeout")
+	check(c.HTTP.IdleTimeout > 0, "http.idle_timeout must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+	check(c.HTTP.ShutdownDelay >= 0, "http.shutdown_delay must not be negative")
+	check((c.HTTP.TLS.CertFile == "") == (c.HTTP.TLS.KeyFile == ""), "http.tls.cert_file and http.tls.key_file must be set together")
+	for _, file := range []string{c.HTTP.TLS.CertFile, c.HTTP.TLS.KeyFile} {
+		if file != "" {
+			_, err := os.Stat(file)
+			check(err == nil, "%s: %v", file, err)
+		}
+	}
+
+	if c.DB.DSN == "" {
+		check(c.DB.Host != "", "db.host is not set")
+		check(c.DB.Port != "", "db.port is not set")
+		check(c.DB.User != "", "db.user is not set")
+		check(c.DB.Password != "", "db.password is not set")
+		check(c.DB.Name != "", "db.name is not set")
+		check(c.DB.SSLMode != "", "db.ssl_mode is not set")
+	}
+	check(c.DB.MaxOpenConns > 0, "db.max_open_conns must be positive")
+	check(c.DB.MaxIdleConns >= 0 && c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "db.max_idle_conns must be from 0 to db.max_open_conns")
+	check(c.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime must not be negative")
+	check(c.DB.ConnMaxIdleTime >= 0, "db.conn_max_idle_time must not be negative")
+	check(c.DB.QueryTimeout > 0, "db.query_timeout must be positive")
 
+	check(c.Auth.JWTSecret != "", "auth.jwt_secret is not set")
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl must be positive")
+	check(c.Auth.RefreshTokenTTL > 0, "auth.refresh_token_ttl must be positive")
+
+	check(oneOf(c.Logging.Level, "debug", "info", "warn", "error"), "logging.level must be debug, info, warn or error")
+	check(oneOf(c.Logging.Format, "json", "text"), "logging.format must be json or text")
+
+	check(oneOf(c.Tracing.Exporter, "none", "stdout", "otlp"), "tracing.exporter must be none, stdout or otlp")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be from 0 to 1")
+
+	check(c.Retention.MaxAge > 0, "retention.max_age must be positive")
+	check(c.Retention.Interval > 0, "retention.interval must be positive")
+
+	return errs
+}
+
+// Redacted возвращает действующие значения всех параметров, секреты скрыты
+func (c *Config) Redacted() map[string]string {
+	values := map[string]string{}
+	for _, s := range newSettings(c) {
+		value := s.get()
+		if s.secret && value != "" {
+			value = "******"
+		}
+		values[s.key] = value
+	}
+	return values
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,228 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupEnv очищает переменные окружения всех параметров и переходит в пустой каталог,
+// чтобы на результат не влияли окружение и .env разработчика
+func setupEnv(t *testing.T) string {
+	t.Helper()
+	var cfg Config
+	for _, s := range newSettings(&cfg) {
+		t.Setenv(s.env, "")
+	}
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_DSN", "postgres://localhost/test")
+	t.Setenv("JWT_SECRET", "secret")
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file bool
+		env  bool
+		flag bool
+		want string
+	}{
+		{"по умолчанию", false, false, false, "8080"},
+		{"файл", true, false, false, "7000"},
+		{"окружение важнее файла", true, true, false, "7100"},
+		{"флаг важнее окружения", true, true, true, "7200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupEnv(t)
+			var args []string
+			if tt.file {
+				path := writeFile(t, dir, "config.yaml", "http:\n  port: 7000\n")
+				args = append(args, "-config", path)
+			}
+			if tt.env {
+				t.Setenv("APP_PORT", "7100")
+			}
+			if tt.flag {
+				args = append(args, "-http-port", "7200")
+			}
+
+			cfg, _, err := Load(args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.HTTP.Port != tt.want {
+				t.Fatalf("http.port = %q, want %q", cfg.HTTP.Port, tt.want)
+			}
+		})
+	}
+}
+
+// Функции, которые меняют данные без участия администратора, по умолчанию выключены
+func TestLoadFeatureDefaults(t *testing.T) {
+	setupEnv(t)
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Features.TestData {
+		t.Error("features.test_data = true, want false by default")
+	}
+	if cfg.Features.AutoMigrate {
+		t.Error("features.auto_migrate = true, want false by default")
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	dir := setupEnv(t)
+	os.Unsetenv("LOG_LEVEL")
+	writeFile(t, dir, ".env", "LOG_LEVEL=debug\n")
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Logging.Level != "debug" {
+		t.Fatalf("logging.level = %q, want debug", cfg.Logging.Level)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	dir := setupEnv(t)
+	path := writeFile(t, dir, "config.toml", "[db]\nquery_timeout = \"2s\"\n\n[features]\nmetrics = false\n")
+
+	cfg, _, err := Load([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DB.QueryTimeout != 2*time.Second {
+		t.Errorf("db.query_timeout = %v, want 2s", cfg.DB.QueryTimeout)
+	}
+	if cfg.Features.Metrics {
+		t.Errorf("features.metrics = true, want false")
+	}
+}
+
+func TestLoadAutoMigrateAlias(t *testing.T) {
+	setupEnv(t)
+	cfg, rest, err := Load([]string{"-auto-migrate", "migrate", "up"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Features.AutoMigrate {
+		t.Errorf("features.auto_migrate = false, want true")
+	}
+	if strings.Join(rest, " ") != "migrate up" {
+		t.Errorf("args = %v, want [migrate up]", rest)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		body  string
+		env   map[string]string
+		wants []string
+	}{
+		{
+			name:  "неизвестный ключ файла",
+			file:  "config.yaml",
+			body:  "http:\n  prot: 8080\n",
+			wants: []string{"http.prot: unknown setting"},
+		},
+		{
+			name:  "списки не поддерживаются",
+			file:  "config.yaml",
+			body:  "http:\n  port: [1, 2]\n",
+			wants: []string{"lists are not supported"},
+		},
+		{
+			name:  "неизвестный формат",
+			file:  "config.json",
+			body:  "{}",
+			wants: []string{"unsupported config format"},
+		},
+		{
+			name:  "неверное значение",
+			env:   map[string]string{"DB_QUERY_TIMEOUT": "soon"},
+			wants: []string{"db.query_timeout"},
+		},
+		{
+			// Все ошибки проверки выводятся сразу списком
+			name: "несколько ошибок проверки",
+			env: map[string]string{
+				"REQUEST_TIMEOUT": "20s",
+				"LOG_LEVEL":       "verbose",
+				"JWT_SECRET":      "",
+				"DB_DSN":          "",
+				"DB_HOST":         "localhost",
+			},
+			wants: []string{
+				"http.write_timeout must be greater than http.request_timeout",
+				"logging.level must be",
+				"auth.jwt_secret is not set",
+				"db.user is not set",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			var args []string
+			if tt.file != "" {
+				args = []string{"-config", writeFile(t, dir, tt.file, tt.body)}
+			}
+
+			_, _, err := Load(args)
+			if err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+			for _, want := range tt.wants {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	setupEnv(t)
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	values := cfg.Redacted()
+	if values["auth.jwt_secret"] != "******" || values["db.dsn"] != "******" {
+		t.Errorf("secrets are not redacted: %v", values)
+	}
+	if values["http.port"] != "8080" {
+		t.Errorf("http.port = %q, want 8080", values["http.port"])
+	}
+}
@@ -0,0 +1,145 @@
+package configs
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting — один параметр конфигурации: ключ в файле, переменная окружения,
+// значение по умолчанию и поле Config, в которое значение записывается
+type setting struct {
+	key    string
+	env    string
+	def    string
+	secret bool
+	isBool bool
+	set    func(value string) error
+	get    func() string
+}
+
+// flagName возвращает имя флага командной строки: http.tls.cert_file -> http-tls-cert-file
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+func newSettings(c *Config) []setting {
+	return []setting{
+		stringSetting("http.port", "APP_PORT", "8080", &c.HTTP.Port),
+		durationSetting("http.request_timeout", "REQUEST_TIMEOUT", "10s", &c.HTTP.RequestTimeout),
+		durationSetting("http.read_timeout", "HTTP_READ_TIMEOUT", "10s", &c.HTTP.ReadTimeout),
+		durationSetting("http.write_timeout", "HTTP_WRITE_TIMEOUT", "15s", &c.HTTP.WriteTimeout),
+		durationSetting("http.idle_timeout", "HTTP_IDLE_TIMEOUT", "60s", &c.HTTP.IdleTimeout),
+		durationSetting("http.shutdown_timeout", "SHUTDOWN_TIMEOUT", "20s", &c.HTTP.ShutdownTimeout),
+		durationSetting("http.shutdown_delay", "SHUTDOWN_DELAY", "5s", &c.HTTP.ShutdownDelay),
+		stringSetting("http.tls.cert_file", "HTTP_TLS_CERT_FILE", "", &c.HTTP.TLS.CertFile),
+		stringSetting("http.tls.key_file", "HTTP_TLS_KEY_FILE", "", &c.HTTP.TLS.KeyFile),
+
+		secret(stringSetting("db.dsn", "DB_DSN", "", &c.DB.DSN)),
+		stringSetting("db.host", "DB_HOST", "", &c.DB.Host),
+		stringSetting("db.port", "DB_PORT", "5432", &c.DB.Port),
+		stringSetting("db.user", "DB_USER", "", &c.DB.User),
+		secret(stringSetting("db.password", "DB_PASS", "", &c.DB.Password)),
+		stringSetting("db.name", "DB_NAME", "", &c.DB.Name),
+		stringSetting("db.ssl_mode", "DB_SSL_MODE", "", &c.DB.SSLMode),
+		intSetting("db.max_open_conns", "DB_MAX_OPEN_CONNS", "10", &c.DB.MaxOpenConns),
+		intSetting("db.max_idle_conns", "DB_MAX_IDLE_CONNS", "5", &c.DB.MaxIdleConns),
+		durationSetting("db.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "30m", &c.DB.ConnMaxLifetime),
+		durationSetting("db.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", "5m", &c.DB.ConnMaxIdleTime),
+		durationSetting("db.query_timeout", "DB_QUERY_TIMEOUT", "5s", &c.DB.QueryTimeout),
+
+		secret(stringSetting("auth.jwt_secret", "JWT_SECRET", "", &c.Auth.JWTSecret)),
+		durationSetting("auth.access_token_ttl", "ACCESS_TOKEN_TTL", "15m", &c.Auth.AccessTokenTTL),
+		durationSetting("auth.refresh_token_ttl", "REFRESH_TOKEN_TTL", "720h", &c.Auth.RefreshTokenTTL),
+
+		stringSetting("logging.level", "LOG_LEVEL", "info", &c.Logging.Level),
+		stringSetting("logging.format", "LOG_FORMAT", "json", &c.Logging.Format),
+
+		stringSetting("tracing.exporter", "TRACING_EXPORTER", "none", &c.Tracing.Exporter),
+		floatSetting("tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "1", &c.Tracing.SampleRatio),
+
+		boolSetting("retention.enabled", "RETENTION_ENABLED", "false", &c.Retention.Enabled),
+		durationSetting("retention.max_age", "RETENTION_MAX_AGE", "720h", &c.Retention.MaxAge),
+		durationSetting("retention.interval", "RETENTION_INTERVAL", "1h", &c.Retention.Interval),
+
+		boolSetting("features.auto_migrate", "AUTO_MIGRATE", "false", &c.Features.AutoMigrate),
+		boolSetting("features.test_data", "FEATURE_TEST_DATA", "false", &c.Features.TestData),
+		boolSetting("features.metrics", "FEATURE_METRICS", "true", &c.Features.Metrics),
+	}
+}
+
+func secret(s setting) setting {
+	s.secret = true
+	return s
+}
+
+func stringSetting(key, env, def string, p *string) setting {
+	return setting{
+		key: key, env: env, def: def,
+		set: func(value string) error {
+			*p = value
+			return nil
+		},
+		get: func() string { return *p },
+	}
+}
+
+func intSetting(key, env, def string, p *int) setting {
+	return setting{
+		key: key, env: env, def: def,
+		set: func(value string) error {
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			*p = v
+			return nil
+		},
+		get: func() string { return strconv.Itoa(*p) },
+	}
+}
+
+func floatSetting(key, env, def string, p *float64) setting {
+	return setting{
+		key: key, env: env, def: def,
+		set: func(value string) error {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			*p = v
+			return nil
+		},
+		get: func() string { return strconv.FormatFloat(*p, 'g', -1, 64) },
+	}
+}
+
+func boolSetting(key, env, def string, p *bool) setting {
+	return setting{
+		key: key, env: env, def: def, isBool: true,
+		set: func(value string) error {
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			*p = v
+			return nil
+		},
+		get: func() string { return strconv.FormatBool(*p) },
+	}
+}
+
+func durationSetting(key, env, def string, p *time.Duration) setting {
+	return setting{
+		key: key, env: env, def: def,
+		set: func(value string) error {
+			v, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			*p = v
+			return nil
+		},
+		get: func() string { return p.String() },
+	}
+}
@@ -0,0 +1,52 @@
+package entity
+
+import "fmt"
+
+type SignUpInput struct {
+	UserName string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+func (i *SignUpInput) Validate() error {
+	user := UserInput{UserName: i.UserName}
+	if err := user.Validate(); err != nil {
+		return err
+	}
+	if len(i.Password) < 8 {
+		return fmt.Errorf("Пароль должен содержать не менее 8 символов")
+	}
+	// bcrypt учитывает только первые 72 байта пароля
+	if len(i.Password) > 72 {
+		return fmt.Errorf("Слишком длинный пароль")
+	}
+	return nil
+}
+
+type SignInInput struct {
+	UserName string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+func (i *SignInInput) Validate() error {
+	if i.UserName == "" || i.Password == "" {
+		return fmt.Errorf("Отсутствует имя пользователя или пароль")
+	}
+	return nil
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func (i *RefreshInput) Validate() error {
+	if i.RefreshToken == "" {
+		return fmt.Errorf("Отсутствует refresh-токен")
+	}
+	return nil
+}
+
+type Tokens struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+}
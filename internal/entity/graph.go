@@ -0,0 +1,17 @@
+package entity
+
+// TaskGraph — задания квеста с зависимостями и их доступность для пользователя
+type TaskGraph struct {
+	QuestID    int  `json:"quest_id" db:"id"`
+	Sequential bool `json:"sequential" db:"sequential"`
+	// Open — квест опубликован, открыт по времени и не заблокирован квестами-условиями
+	Open  bool            `json:"open" db:"open"`
+	Nodes []TaskGraphNode `json:"nodes" db:"-"`
+}
+
+// TaskGraphNode — задание в графе. Available — пользователь может выполнить его сейчас
+type TaskGraphNode struct {
+	TaskStatus
+	DependsOn []int `json:"depends_on"`
+	Available bool  `json:"available"`
+}
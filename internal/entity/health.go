@@ -0,0 +1,27 @@
+package entity
+
+// Readiness — результат проверки готовности сервиса принимать трафик
+type Readiness struct {
+	Ready      bool            `json:"ready"`
+	Draining   bool            `json:"draining"`
+	Database   string          `json:"database"`
+	Migrations MigrationsState `json:"migrations"`
+	Pool       PoolStats       `json:"pool"`
+}
+
+type MigrationsState struct {
+	Version int  `json:"version"`
+	Latest  int  `json:"latest"`
+	Dirty   bool `json:"dirty"`
+	Pending int  `json:"pending"`
+}
+
+// PoolStats — состояние пула соединений с БД
+type PoolStats struct {
+	MaxOpen      int    `json:"max_open"`
+	Open         int    `json:"open"`
+	InUse        int    `json:"in_use"`
+	Idle         int    `json:"idle"`
+	WaitCount    int64  `json:"wait_count"`
+	WaitDuration string `json:"wait_duration"`
+}
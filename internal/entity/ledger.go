@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Основания проводок по балансу пользователя
+const (
+	LedgerReasonTask       = "task"
+	LedgerReasonQuestBonus = "quest_bonus"
+	LedgerReasonAdmin      = "admin"
+	LedgerReasonPurchase   = "purchase"
+)
+
+// Счета журнала. Проводка по счёту пользователя уравновешивается проводкой
+// на системном счёте: выплаты идут со счёта payouts, покупки зачисляются на счёт purchases
+const (
+	LedgerAccountUser      = "user"
+	LedgerAccountPayouts   = "payouts"
+	LedgerAccountPurchases = "purchases"
+)
+
+type LedgerEntry struct {
+	ID           int       `json:"id,omitempty" db:"id"`
+	UserID       int       `json:"user_id,omitempty" db:"user_id"`
+	Amount       int       `json:"amount,omitempty" db:"amount"`
+	BalanceAfter int       `json:"balance_after" db:"balance_after"`
+	Reason       string    `json:"reason,omitempty" db:"reason"`
+	SourceID     *int      `json:"source_id,omitempty" db:"source_id"`
+	CreatedAt    time.Time `json:"created_at,omitempty" db:"created_at"`
+	// TransactionID связывает проводку со встречной проводкой на системном счёте
+	TransactionID int `json:"transaction_id,omitempty" db:"transaction_id"`
+}
+
+// LedgerEntryInput — ручная проводка: корректировка администратором или покупка
+type LedgerEntryInput struct {
+	UserID   int    `json:"-"`
+	Amount   int    `json:"amount,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	SourceID *int   `json:"source_id,omitempty"`
+}
+
+func (e *LedgerEntryInput) Validate() error {
+	if e.Amount == 0 {
+		return fmt.Errorf("Сумма проводки не может быть нулевой")
+	}
+	switch e.Reason {
+	case LedgerReasonAdmin:
+	case LedgerReasonPurchase:
+		if e.Amount > 0 {
+			return fmt.Errorf("Покупка должна списывать средства")
+		}
+	default:
+		return fmt.Errorf("Недопустимое основание проводки")
+	}
+	return nil
+}
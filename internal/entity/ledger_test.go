@@ -0,0 +1,44 @@
+package entity
+
+import "testing"
+
+func TestLedgerEntryInputValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   LedgerEntryInput
+		wantErr bool
+	}{
+		{"admin credit", LedgerEntryInput{Amount: 10, Reason: LedgerReasonAdmin}, false},
+		{"admin debit", LedgerEntryInput{Amount: -10, Reason: LedgerReasonAdmin}, false},
+		{"purchase", LedgerEntryInput{Amount: -10, Reason: LedgerReasonPurchase}, false},
+		{"zero amount", LedgerEntryInput{Amount: 0, Reason: LedgerReasonAdmin}, true},
+		{"purchase credit", LedgerEntryInput{Amount: 10, Reason: LedgerReasonPurchase}, true},
+		{"task reason", LedgerEntryInput{Amount: 10, Reason: LedgerReasonTask}, true},
+		{"unknown reason", LedgerEntryInput{Amount: 10, Reason: "gift"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.input.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPageValidate(t *testing.T) {
+	tests := []struct {
+		page    Page
+		wantErr bool
+	}{
+		{Page{Limit: 1}, false},
+		{Page{Limit: MaxPageLimit, Offset: 40}, false},
+		{Page{Limit: 0}, true},
+		{Page{Limit: MaxPageLimit + 1}, true},
+		{Page{Limit: 10, Offset: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.page.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() error = %v, wantErr %v", tt.page, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestOptionalTimeUnmarshal(t *testing.T) {
+	var patch struct {
+		Absent  OptionalTime `json:"absent"`
+		Null    OptionalTime `json:"null"`
+		Present OptionalTime `json:"present"`
+	}
+	body := `{"null": null, "present": "2024-05-01T10:00:00Z"}`
+	if err := json.Unmarshal([]byte(body), &patch); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if patch.Absent.Set || patch.Absent.Time != nil {
+		t.Errorf("absent = %+v, want unset", patch.Absent)
+	}
+	if !patch.Null.Set || patch.Null.Time != nil {
+		t.Errorf("null = %+v, want set without time", patch.Null)
+	}
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if !patch.Present.Set || patch.Present.Time == nil || !patch.Present.Time.Equal(want) {
+		t.Errorf("present = %+v, want set to %v", patch.Present, want)
+	}
+}
+
+func TestOptionalTimeUnmarshalInvalid(t *testing.T) {
+	var o OptionalTime
+	if err := json.Unmarshal([]byte(`"not a time"`), &o); err == nil {
+		t.Fatal("Unmarshal() error = nil, want error")
+	}
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "fmt"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+type Page struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+func (p *Page) Validate() error {
+	if p.Limit < 1 || p.Limit > MaxPageLimit {
+		return fmt.Errorf("Параметр limit должен быть от 1 до %d", MaxPageLimit)
+	}
+	if p.Offset < 0 {
+		return fmt.Errorf("Параметр offset не может быть отрицательным")
+	}
+	return nil
+}
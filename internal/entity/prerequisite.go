@@ -0,0 +1,41 @@
+package entity
+
+import "fmt"
+
+// MaxPrerequisites — сколько квестов-условий может быть у одного квеста
+const MaxPrerequisites = 50
+
+// QuestPrerequisites — квесты, которые пользователь должен завершить, чтобы открыть квест
+type QuestPrerequisites struct {
+	QuestID         int   `json:"quest_id"`
+	Version         int   `json:"version,omitempty"`
+	PrerequisiteIDs []int `json:"prerequisite_ids"`
+}
+
+// QuestPrerequisitesInput заменяет весь набор квестов-условий. Пустой список снимает условия
+type QuestPrerequisitesInput struct {
+	PrerequisiteIDs []int `json:"prerequisite_ids"`
+}
+
+func (p *QuestPrerequisitesInput) Validate(questID int) error {
+	if p.PrerequisiteIDs == nil {
+		return fmt.Errorf("Отсутствует список prerequisite_ids")
+	}
+	if len(p.PrerequisiteIDs) > MaxPrerequisites {
+		return fmt.Errorf("Слишком много квестов-условий, максимум %d", MaxPrerequisites)
+	}
+	seen := make(map[int]bool, len(p.PrerequisiteIDs))
+	for _, id := range p.PrerequisiteIDs {
+		if id <= 0 {
+			return fmt.Errorf("Неверный ID квеста-условия")
+		}
+		if id == questID {
+			return fmt.Errorf("Квест не может быть условием самого себя")
+		}
+		if seen[id] {
+			return fmt.Errorf("Квест-условие %d указан дважды", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package entity
+
+import "testing"
+
+func TestQuestPrerequisitesInputValidate(t *testing.T) {
+	tooMany := make([]int, MaxPrerequisites+1)
+	for i := range tooMany {
+		tooMany[i] = i + 2
+	}
+	tests := []struct {
+		name    string
+		ids     []int
+		wantErr bool
+	}{
+		{"нет списка", nil, true},
+		{"пустой список снимает условия", []int{}, false},
+		{"условия", []int{2, 3}, false},
+		{"неверный ID", []int{0}, true},
+		{"сам себе условие", []int{1}, true},
+		{"повтор", []int{2, 2}, true},
+		{"слишком много", tooMany, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := QuestPrerequisitesInput{PrerequisiteIDs: tt.ids}
+			err := input.Validate(1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,23 +1,73 @@
 package entity
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Quest struct {
-	ID    int    `json:"id,omitempty"`
-	Name  string `json:"name,omitempty"`
-	Cost  int    `json:"cost,omitempty"`
-	Tasks []Task `json:"tasks,omitempty"`
+	ID         int       `json:"id,omitempty" db:"id"`
+	Name       string    `json:"name,omitempty" db:"name"`
+	Cost       int       `json:"cost,omitempty" db:"cost"`
+	CreatedAt  time.Time `json:"created_at,omitempty" db:"created_at"`
+	Status     string    `json:"status,omitempty" db:"status"`
+	Sequential bool      `json:"sequential" db:"sequential"`
+	// StartsAt и EndsAt — окно доступности квеста. Пустая граница не ограничивает
+	StartsAt  *time.Time `json:"starts_at,omitempty" db:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	Version   int        `json:"version,omitempty" db:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	Tasks     []Task     `json:"tasks"`
 }
 
 type QuestInput struct {
-	Name  string      `json:"name,omitempty"`
-	Cost  int         `json:"cost,omitempty"`
-	Tasks []TaskInput `json:"tasks,omitempty"`
+	Name       string      `json:"name,omitempty"`
+	Cost       int         `json:"cost,omitempty"`
+	Sequential bool        `json:"sequential,omitempty"`
+	StartsAt   *time.Time  `json:"starts_at,omitempty"`
+	EndsAt     *time.Time  `json:"ends_at,omitempty"`
+	Tasks      []TaskInput `json:"tasks,omitempty"`
 }
 
-type QuestInputForUpdate struct {
-	Name string `json:"name,omitempty"`
-	Cost int    `json:"cost,omitempty"`
+// AnyVersion — версия из If-Match: *. Запись изменяется без проверки версии
+const AnyVersion = 0
+
+// Статусы жизненного цикла квеста. Новый квест создаётся черновиком
+const (
+	QuestStatusDraft     = "draft"
+	QuestStatusPublished = "published"
+	QuestStatusPaused    = "paused"
+	QuestStatusArchived  = "archived"
+)
+
+// questTransitions — статусы, из которых квест можно перевести в статус-ключ. Из архива выхода нет
+var questTransitions = map[string][]string{
+	QuestStatusPublished: {QuestStatusDraft, QuestStatusPaused},
+	QuestStatusPaused:    {QuestStatusPublished},
+	QuestStatusArchived:  {QuestStatusDraft, QuestStatusPublished, QuestStatusPaused},
+}
+
+// QuestStatusesBefore возвращает статусы, из которых квест можно перевести в status
+func QuestStatusesBefore(status string) []string {
+	return questTransitions[status]
+}
+
+// QuestVisibility — какие квесты пользователь видит помимо открытых опубликованных
+type QuestVisibility struct {
+	// Closed — квесты вне окна доступности
+	Closed bool
+	// Unpublished — черновики, приостановленные и архивные квесты
+	Unpublished bool
+}
+
+// QuestPatch — частичное обновление квеста. Отсутствующие в запросе поля не меняются
+// Границы окна доступности очищаются явным null
+type QuestPatch struct {
+	Name       *string      `json:"name,omitempty"`
+	Cost       *int         `json:"cost,omitempty"`
+	Sequential *bool        `json:"sequential,omitempty"`
+	StartsAt   OptionalTime `json:"starts_at" swaggertype:"string" format:"date-time"`
+	EndsAt     OptionalTime `json:"ends_at" swaggertype:"string" format:"date-time"`
 }
 
 func (q *QuestInput) Validate() error {
@@ -33,6 +83,9 @@ func (q *QuestInput) Validate() error {
 	if q.Cost < 0 {
 		return fmt.Errorf("Стоимость квеста не может быть отрицательной")
 	}
+	if q.StartsAt != nil && q.EndsAt != nil && !q.EndsAt.After(*q.StartsAt) {
+		return fmt.Errorf("Окончание квеста должно быть позже начала")
+	}
 	if len(q.Tasks) == 0 {
 		return fmt.Errorf("Отсутствуют задания квеста")
 	}
@@ -40,22 +93,109 @@ func (q *QuestInput) Validate() error {
 		if err := task.Validate(); err != nil {
 			return err
 		}
+		// У заданий нового квеста ещё нет ID, на которые можно сослаться
+		if len(task.DependsOn) > 0 {
+			return fmt.Errorf("Зависимости заданий задаются после создания квеста")
+		}
 	}
 
 	return nil
 }
 
-func (q *QuestInput) ValidateForUpdate() error {
-	if len(q.Name) > 150 {
+func (q *QuestPatch) Validate() error {
+	if q.Name == nil && q.Cost == nil && q.Sequential == nil && !q.StartsAt.Set && !q.EndsAt.Set {
+		return fmt.Errorf("Нет полей для обновления")
+	}
+	if q.Name != nil && *q.Name == "" {
+		return fmt.Errorf("Название квеста слишком короткое")
+	}
+	if q.Name != nil && len(*q.Name) > 150 {
 		return fmt.Errorf("Название квеста слишком длинное")
 	}
-	if q.Cost < 0 {
+	if q.Cost != nil && *q.Cost < 0 {
 		return fmt.Errorf("Стоимость квеста не может быть отрицательной")
 	}
+	if q.StartsAt.Time != nil && q.EndsAt.Time != nil && !q.EndsAt.Time.After(*q.StartsAt.Time) {
+		return fmt.Errorf("Окончание квеста должно быть позже начала")
+	}
 	return nil
 }
 
+// QuestProgress — прогресс пользователя по квесту
 type QuestProgress struct {
-	UserID  int `json:"user_id,omitempty"`
-	QuestID int `json:"quest_id,omitempty"`
+	QuestID        int          `json:"quest_id" db:"quest_id"`
+	QuestName      string       `json:"quest_name" db:"quest_name"`
+	CompletedTasks int          `json:"completed_tasks" db:"completed_tasks"`
+	TotalTasks     int          `json:"total_tasks" db:"total_tasks"`
+	Percent        int          `json:"percent" db:"-"`
+	IsCompleted    bool         `json:"is_completed" db:"is_completed"`
+	IsLocked       bool         `json:"is_locked" db:"is_locked"`
+	CompletedAt    *time.Time   `json:"completed_at,omitempty" db:"completed_at"`
+	Tasks          []TaskStatus `json:"tasks,omitempty" db:"-"`
+}
+
+// CalculatePercent вычисляет процент выполненных заданий
+func (p *QuestProgress) CalculatePercent() {
+	if p.TotalTasks == 0 {
+		p.Percent = 0
+		if p.IsCompleted {
+			p.Percent = 100
+		}
+		return
+	}
+	p.Percent = p.CompletedTasks * 100 / p.TotalTasks
+}
+
+// Поля сортировки списка квестов
+const (
+	QuestSortCost      = "cost"
+	QuestSortCreatedAt = "created_at"
+	QuestSortName      = "name"
+)
+
+// QuestFilter — параметры выборки списка квестов
+type QuestFilter struct {
+	// Visibility — какие скрытые квесты видит пользователь, Status — выборка одного статуса
+	Visibility       QuestVisibility
+	Status           string
+	MinCost          *int
+	MaxCost          *int
+	Name             string
+	HasReusableTasks *bool
+	Sort             string
+	Desc             bool
+	Limit            int
+	// Cursor — непрозрачный курсор из next_cursor предыдущей страницы
+	Cursor string
+}
+
+func (f *QuestFilter) Validate() error {
+	if f.Limit < 1 || f.Limit > MaxPageLimit {
+		return fmt.Errorf("Параметр limit должен быть от 1 до %d", MaxPageLimit)
+	}
+	if f.MinCost != nil && *f.MinCost < 0 || f.MaxCost != nil && *f.MaxCost < 0 {
+		return fmt.Errorf("Стоимость не может быть отрицательной")
+	}
+	if f.MinCost != nil && f.MaxCost != nil && *f.MinCost > *f.MaxCost {
+		return fmt.Errorf("Минимальная стоимость больше максимальной")
+	}
+	if len(f.Name) > 150 {
+		return fmt.Errorf("Слишком длинная строка поиска")
+	}
+	switch f.Sort {
+	case QuestSortCost, QuestSortCreatedAt, QuestSortName:
+	default:
+		return fmt.Errorf("Недопустимое поле сортировки")
+	}
+	switch f.Status {
+	case "", QuestStatusDraft, QuestStatusPublished, QuestStatusPaused, QuestStatusArchived:
+	default:
+		return fmt.Errorf("Недопустимый статус квеста")
+	}
+	return nil
+}
+
+type QuestPage struct {
+	Quests     []Quest `json:"quests"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,151 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestQuestProgressCalculatePercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress QuestProgress
+		want     int
+	}{
+		{"ничего не выполнено", QuestProgress{TotalTasks: 4}, 0},
+		{"часть заданий", QuestProgress{CompletedTasks: 1, TotalTasks: 3}, 33},
+		{"все задания", QuestProgress{CompletedTasks: 3, TotalTasks: 3}, 100},
+		{"квест без заданий", QuestProgress{}, 0},
+		// Все задания завершённого квеста могли быть удалены позже
+		{"завершённый квест без заданий", QuestProgress{IsCompleted: true}, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.progress.CalculatePercent()
+			if tt.progress.Percent != tt.want {
+				t.Fatalf("Percent = %d, want %d", tt.progress.Percent, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuestInputValidateWindow(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	after := start.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		startsAt *time.Time
+		endsAt   *time.Time
+		wantErr  bool
+	}{
+		{"без окна", nil, nil, false},
+		{"только начало", &start, nil, false},
+		{"только окончание", nil, &start, false},
+		{"окончание позже начала", &start, &after, false},
+		{"окончание равно началу", &start, &start, true},
+		{"окончание раньше начала", &start, &before, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := QuestInput{
+				Name:     "quest",
+				StartsAt: tt.startsAt,
+				EndsAt:   tt.endsAt,
+				Tasks:    []TaskInput{{Name: "task"}},
+			}
+			err := input.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuestPatchValidateWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"нет полей", `{}`, true},
+		{"очистка начала", `{"starts_at": null}`, false},
+		{"очистка окончания", `{"ends_at": null}`, false},
+		{"только начало", `{"starts_at": "2024-05-01T10:00:00Z"}`, false},
+		{"окончание позже начала", `{"starts_at": "2024-05-01T10:00:00Z", "ends_at": "2024-05-02T10:00:00Z"}`, false},
+		{"окончание равно началу", `{"starts_at": "2024-05-01T10:00:00Z", "ends_at": "2024-05-01T10:00:00Z"}`, true},
+		{"окончание раньше начала", `{"starts_at": "2024-05-02T10:00:00Z", "ends_at": "2024-05-01T10:00:00Z"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch QuestPatch
+			if err := json.Unmarshal([]byte(tt.body), &patch); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			err := patch.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuestStatusesBefore(t *testing.T) {
+	tests := []struct {
+		status string
+		want   []string
+	}{
+		{QuestStatusDraft, nil},
+		{QuestStatusPublished, []string{QuestStatusDraft, QuestStatusPaused}},
+		{QuestStatusPaused, []string{QuestStatusPublished}},
+		{QuestStatusArchived, []string{QuestStatusDraft, QuestStatusPublished, QuestStatusPaused}},
+		{"unknown", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			got := QuestStatusesBefore(tt.status)
+			if len(got) != len(tt.want) {
+				t.Fatalf("QuestStatusesBefore(%q) = %v, want %v", tt.status, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("QuestStatusesBefore(%q) = %v, want %v", tt.status, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestQuestStatusesBeforeArchiveIsFinal(t *testing.T) {
+	for _, status := range []string{QuestStatusDraft, QuestStatusPublished, QuestStatusPaused} {
+		for _, from := range QuestStatusesBefore(status) {
+			if from == QuestStatusArchived {
+				t.Errorf("квест выходит из архива в статус %q", status)
+			}
+		}
+	}
+}
+
+func TestQuestFilterValidateStatus(t *testing.T) {
+	tests := []struct {
+		status  string
+		wantErr bool
+	}{
+		{"", false},
+		{QuestStatusDraft, false},
+		{QuestStatusPublished, false},
+		{QuestStatusPaused, false},
+		{QuestStatusArchived, false},
+		{"deleted", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			filter := QuestFilter{Limit: 10, Sort: QuestSortCreatedAt, Status: tt.status}
+			err := filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
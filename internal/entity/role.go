@@ -0,0 +1,78 @@
+package entity
+
+import "fmt"
+
+// Роли пользователей
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RolePlayer = "player"
+)
+
+type Permission string
+
+// Права доступа к операциям API
+const (
+	PermQuestsRead    Permission = "quests:read"
+	PermTasksComplete Permission = "tasks:complete"
+	PermAccountRead   Permission = "account:read"
+	PermPurchase      Permission = "account:purchase"
+	PermQuestsManage  Permission = "quests:manage"
+	PermSeedData      Permission = "data:seed"
+	PermBalanceAdjust Permission = "balance:adjust"
+	PermUsersManage   Permission = "users:manage"
+	PermTrashManage   Permission = "trash:manage"
+	// PermQuestsReadHidden — видеть квесты вне окна доступности
+	PermQuestsReadHidden Permission = "quests:read_hidden"
+)
+
+var playerPermissions = []Permission{
+	PermQuestsRead,
+	PermTasksComplete,
+	PermAccountRead,
+	PermPurchase,
+}
+
+var rolePermissions = map[string][]Permission{
+	RolePlayer: playerPermissions,
+	RoleEditor: append([]Permission{PermQuestsManage}, playerPermissions...),
+	RoleAdmin: append([]Permission{
+		PermQuestsManage,
+		PermQuestsReadHidden,
+		PermSeedData,
+		PermBalanceAdjust,
+		PermUsersManage,
+		PermTrashManage,
+	}, playerPermissions...),
+}
+
+// HasPermission сообщает, разрешена ли операция роли
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Identity — аутентифицированный пользователь запроса
+type Identity struct {
+	UserID int
+	Role   string
+}
+
+func (i *Identity) Can(permission Permission) bool {
+	return HasPermission(i.Role, permission)
+}
+
+type RoleInput struct {
+	Role string `json:"role,omitempty"`
+}
+
+func (r *RoleInput) Validate() error {
+	if _, ok := rolePermissions[r.Role]; !ok {
+		return fmt.Errorf("Недопустимая роль")
+	}
+	return nil
+}
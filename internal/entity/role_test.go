@@ -0,0 +1,36 @@
+package entity
+
+import "testing"
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		want       bool
+	}{
+		{RolePlayer, PermTasksComplete, true},
+		{RolePlayer, PermQuestsManage, false},
+		{RoleEditor, PermQuestsManage, true},
+		{RoleEditor, PermUsersManage, false},
+		{RoleAdmin, PermUsersManage, true},
+		{RoleAdmin, PermPurchase, true},
+		{"", PermQuestsRead, false},
+		{"root", PermQuestsRead, false},
+	}
+	for _, tt := range tests {
+		if got := HasPermission(tt.role, tt.permission); got != tt.want {
+			t.Errorf("HasPermission(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestRoleInputValidate(t *testing.T) {
+	for _, role := range []string{RoleAdmin, RoleEditor, RolePlayer} {
+		if err := (&RoleInput{Role: role}).Validate(); err != nil {
+			t.Errorf("Validate(%q) = %v", role, err)
+		}
+	}
+	if err := (&RoleInput{Role: "root"}).Validate(); err == nil {
+		t.Error("Validate(\"root\") = nil, want error")
+	}
+}
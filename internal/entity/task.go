@@ -2,21 +2,31 @@ package entity
 
 import (
 	"fmt"
+	"time"
 )
 
 type Task struct {
-	ID         int    `json:"id,omitempty" db:"id"`
-	QuestID    int    `json:"quest_id,omitempty" db:"quest_id"`
-	Name       string `json:"name,omitempty" db:"name"`
-	IsReusable bool   `json:"is_reusable,omitempty" db:"is_reusable"`
-	Cost       int    `json:"cost,omitempty" db:"cost"`
+	ID         int        `json:"id,omitempty" db:"id"`
+	QuestID    int        `json:"quest_id,omitempty" db:"quest_id"`
+	Name       string     `json:"name,omitempty" db:"name"`
+	IsReusable bool       `json:"is_reusable,omitempty" db:"is_reusable"`
+	Cost       int        `json:"cost,omitempty" db:"cost"`
+	Position   int        `json:"position,omitempty" db:"position"`
+	Version    int        `json:"version,omitempty" db:"version"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// DependsOn — задания того же квеста, которые выполняются раньше этого
+	DependsOn []int `json:"depends_on,omitempty" db:"-"`
 }
 
+// MaxDependencies — сколько заданий может быть в зависимостях одного задания
+const MaxDependencies = 50
+
 type TaskInput struct {
 	QuestID    int    `json:"quest_id,omitempty" db:"quest_id"`
 	Name       string `json:"name,omitempty"`
 	IsReusable bool   `json:"is_reusable,omitempty"`
 	Cost       int    `json:"cost,omitempty"`
+	DependsOn  []int  `json:"depends_on,omitempty"`
 }
 
 func (t *TaskInput) ValidateForCreate() error {
@@ -35,13 +45,10 @@ func (t *TaskInput) ValidateForCreate() error {
 	if t.Cost < 0 {
 		return fmt.Errorf("Стоимость задания не может быть отрицательной")
 	}
-	return nil
+	return validateDependencies(t.DependsOn)
 }
 
-func (t *TaskInput) ValidateForUpdate() error {
-	if t.QuestID == 0 {
-		return fmt.Errorf("Отсутствует ID квеста")
-	}
+func (t *TaskInput) Validate() error {
 	if t.Name == "" {
 		return fmt.Errorf("отсутствует название задания")
 	}
@@ -55,27 +62,58 @@ func (t *TaskInput) ValidateForUpdate() error {
 		return fmt.Errorf("Стоимость задания не может быть отрицательной")
 	}
 	return nil
+
 }
 
-func (t *TaskInput) Validate() error {
-	if t.Name == "" {
-		return fmt.Errorf("отсутствует название задания")
+// TaskPatch — частичное обновление задания. Отсутствующие в запросе поля не меняются.
+// DependsOn заменяет зависимости целиком, пустой список их снимает
+type TaskPatch struct {
+	Name       *string `json:"name,omitempty"`
+	IsReusable *bool   `json:"is_reusable,omitempty"`
+	Cost       *int    `json:"cost,omitempty"`
+	DependsOn  *[]int  `json:"depends_on,omitempty"`
+}
+
+func (t *TaskPatch) Validate() error {
+	if t.Name == nil && t.IsReusable == nil && t.Cost == nil && t.DependsOn == nil {
+		return fmt.Errorf("Нет полей для обновления")
 	}
-	if len(t.Name) < 1 {
+	if t.Name != nil && *t.Name == "" {
 		return fmt.Errorf("Название задания слишком короткое")
 	}
-	if len(t.Name) > 150 {
+	if t.Name != nil && len(*t.Name) > 150 {
 		return fmt.Errorf("Название задания слишком длинное")
 	}
-	if t.Cost < 0 {
+	if t.Cost != nil && *t.Cost < 0 {
 		return fmt.Errorf("Стоимость задания не может быть отрицательной")
 	}
+	if t.DependsOn != nil {
+		return validateDependencies(*t.DependsOn)
+	}
 	return nil
+}
 
+// validateDependencies проверяет список заданий, от которых зависит задание
+func validateDependencies(ids []int) error {
+	if len(ids) > MaxDependencies {
+		return fmt.Errorf("Слишком много зависимостей задания, максимум %d", MaxDependencies)
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return fmt.Errorf("Неверный ID задания в зависимостях")
+		}
+		if seen[id] {
+			return fmt.Errorf("Задание %d указано в зависимостях дважды", id)
+		}
+		seen[id] = true
+	}
+	return nil
 }
 
 type TaskProgress struct {
-	UserID int `json:"user_id,omitempty" db:"user_id"`
+	// Заполняется из токена аутентифицированного пользователя
+	UserID int `json:"-" db:"user_id"`
 	TaskID int `json:"task_id,omitempty" db:"task_id"`
 }
 
@@ -90,6 +128,62 @@ func (t *TaskProgress) Validate() error {
 }
 
 type TaskStatus struct {
-	TaskID      int  `json:"task_id,omitempty" db:"task_id"`
-	IsCompleted bool `json:"is_completed,omitempty" db:"is_completed"`
+	TaskID          int        `json:"task_id,omitempty" db:"task_id"`
+	Name            string     `json:"name,omitempty" db:"name"`
+	IsReusable      bool       `json:"is_reusable,omitempty" db:"is_reusable"`
+	Position        int        `json:"position,omitempty" db:"position"`
+	IsCompleted     bool       `json:"is_completed" db:"is_completed"`
+	CompletedCount  int        `json:"completed_count" db:"completed_count"`
+	LastCompletedAt *time.Time `json:"last_completed_at,omitempty" db:"last_completed_at"`
+}
+
+// TaskCompletionState — данные, прочитанные внутри транзакции выполнения задания
+type TaskCompletionState struct {
+	UserFound bool
+	Task      *Task
+	// Deleted — удалено задание или его квест
+	Deleted bool
+	// QuestStatus — статус квеста задания, QuestSequential — задания выполняются по порядку
+	QuestStatus     string
+	QuestSequential bool
+	// QuestNotStarted и QuestEnded — текущее время вне окна доступности квеста
+	QuestNotStarted bool
+	QuestEnded      bool
+	// QuestLocked — пользователь ещё не завершил квесты-условия
+	QuestLocked bool
+	// DependsOn — живые задания, которые должны быть выполнены раньше
+	DependsOn []int
+
+	CompletedCount int
+	TaskStatuses   []TaskStatus
+}
+
+type TaskCompletionResult struct {
+	TaskID         int  `json:"task_id,omitempty"`
+	QuestID        int  `json:"quest_id,omitempty"`
+	Reward         int  `json:"reward,omitempty"`
+	QuestCompleted bool `json:"quest_completed,omitempty"`
+	QuestReward    int  `json:"quest_reward,omitempty"`
+}
+
+// TaskOrderInput — новый порядок всех живых заданий квеста
+type TaskOrderInput struct {
+	TaskIDs []int `json:"task_ids"`
+}
+
+func (o *TaskOrderInput) Validate() error {
+	if len(o.TaskIDs) == 0 {
+		return fmt.Errorf("Отсутствует список task_ids")
+	}
+	seen := make(map[int]bool, len(o.TaskIDs))
+	for _, id := range o.TaskIDs {
+		if id <= 0 {
+			return fmt.Errorf("Неверный ID задания")
+		}
+		if seen[id] {
+			return fmt.Errorf("Задание %d указано дважды", id)
+		}
+		seen[id] = true
+	}
+	return nil
 }
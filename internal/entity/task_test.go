@@ -0,0 +1,89 @@
+package entity
+
+import "testing"
+
+func TestPatchValidate(t *testing.T) {
+	name, empty := "name", ""
+	cost, negative := 5, -1
+	reusable := true
+	tests := []struct {
+		name    string
+		patch   interface{ Validate() error }
+		wantErr bool
+	}{
+		{"квест: имя", &QuestPatch{Name: &name}, false},
+		{"квест: стоимость", &QuestPatch{Cost: &cost}, false},
+		{"квест: пустой", &QuestPatch{}, true},
+		{"квест: пустое имя", &QuestPatch{Name: &empty}, true},
+		{"квест: отрицательная стоимость", &QuestPatch{Cost: &negative}, true},
+		{"задание: повторяемость", &TaskPatch{IsReusable: &reusable}, false},
+		{"задание: пустой", &TaskPatch{}, true},
+		{"задание: пустое имя", &TaskPatch{Name: &empty}, true},
+		{"задание: отрицательная стоимость", &TaskPatch{Cost: &negative}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.patch.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTaskOrderInputValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []int
+		wantErr bool
+	}{
+		{"порядок", []int{3, 1, 2}, false},
+		{"одно задание", []int{1}, false},
+		{"пустой список", nil, true},
+		{"повтор", []int{1, 2, 1}, true},
+		{"неверный ID", []int{1, 0}, true},
+		{"отрицательный ID", []int{-1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := TaskOrderInput{TaskIDs: tt.ids}
+			err := input.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tooMany := make([]int, MaxDependencies+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+	tests := []struct {
+		name    string
+		ids     []int
+		wantErr bool
+	}{
+		{"без зависимостей", nil, false},
+		{"зависимости", []int{1, 2}, false},
+		{"максимум", tooMany[:MaxDependencies], false},
+		{"слишком много", tooMany, true},
+		{"повтор", []int{1, 1}, true},
+		{"неверный ID", []int{0}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDependencies(tt.ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateDependencies() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuestInputRejectsDependencies(t *testing.T) {
+	input := QuestInput{Name: "quest", Tasks: []TaskInput{{Name: "task", DependsOn: []int{1}}}}
+	if err := input.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error")
+	}
+}
@@ -0,0 +1,24 @@
+package entity
+
+// Исход окончательного удаления записи из корзины
+const (
+	// PurgeDeleted — запись стёрта из БД
+	PurgeDeleted = "deleted"
+	// PurgeAnonymized — на запись ссылается история выполнений, поэтому она обезличена и скрыта из корзины
+	PurgeAnonymized = "anonymized"
+)
+
+// AnonymizedName заменяет название обезличенного квеста или задания
+const AnonymizedName = "[удалено]"
+
+type PurgeResult struct {
+	ID      int    `json:"id"`
+	Outcome string `json:"outcome"`
+}
+
+// PurgeStats — итог очистки корзины фоновой задачей
+type PurgeStats struct {
+	Quests     int
+	Tasks      int
+	Anonymized int
+}
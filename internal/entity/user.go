@@ -6,6 +6,9 @@ type User struct {
 	ID       int    `json:"user_id,omitempty" db:"id"`
 	UserName string `json:"username,omitempty" db:"username"`
 	Balance  int    `json:"balance,omitempty" db:"balance"`
+	Role     string `json:"role,omitempty" db:"role"`
+
+	PasswordHash *string `json:"-" db:"password_hash"`
 }
 
 type UserInput struct {
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"quest_service/internal/service"
+)
+
+// Машиночитаемые коды ошибок в ответе
+const (
+	codeBadRequest           = "bad_request"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeValidation           = "validation_failed"
+	codeNotFound             = "not_found"
+	codeDeleted              = "deleted"
+	codeAlreadyCompleted     = "already_completed"
+	codeOutsideWindow        = "outside_window"
+	codeQuestInactive        = "quest_inactive"
+	codeQuestLocked          = "quest_locked"
+	codeOutOfOrder           = "out_of_order"
+	codeConflict             = "conflict"
+	codePreconditionFailed   = "precondition_failed"
+	codePreconditionRequired = "precondition_required"
+	codeTxConflict           = "transaction_conflict"
+	codeInternal             = "internal_error"
+	codeRequestTimeout       = "request_timeout"
+	codeDBTimeout            = "db_timeout"
+	codeClientClosed         = "client_closed_request"
+)
+
+// retryAfter — через сколько секунд повторять запрос, не прошедший из-за конфликта транзакций
+const retryAfter = "1"
+
+// statusClientClosedRequest — нестандартный статус для запросов, клиент которых отключился
+const statusClientClosedRequest = 499
+
+// statusCodes — коды по умолчанию для ответов, сформированных без ошибки сервиса
+var statusCodes = map[int]string{
+	http.StatusBadRequest:           codeBadRequest,
+	http.StatusUnauthorized:         codeUnauthorized,
+	http.StatusForbidden:            codeForbidden,
+	http.StatusNotFound:             codeNotFound,
+	http.StatusGone:                 codeDeleted,
+	http.StatusConflict:             codeConflict,
+	http.StatusPreconditionFailed:   codePreconditionFailed,
+	http.StatusPreconditionRequired: codePreconditionRequired,
+	http.StatusUnprocessableEntity:  codeValidation,
+	http.StatusInternalServerError:  codeInternal,
+	http.StatusServiceUnavailable:   codeDBTimeout,
+	http.StatusGatewayTimeout:       codeRequestTimeout,
+	statusClientClosedRequest:       codeClientClosed,
+}
+
+// serviceErrors сопоставляет виды ошибок сервисного слоя со статусом и кодом ответа
+var serviceErrors = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{service.ErrNotFound, http.StatusNotFound, codeNotFound},
+	{service.ErrDeleted, http.StatusGone, codeDeleted},
+	{service.ErrAlreadyCompleted, http.StatusConflict, codeAlreadyCompleted},
+	{service.ErrOutsideWindow, http.StatusConflict, codeOutsideWindow},
+	{service.ErrQuestInactive, http.StatusConflict, codeQuestInactive},
+	{service.ErrQuestLocked, http.StatusConflict, codeQuestLocked},
+	{service.ErrOutOfOrder, http.StatusConflict, codeOutOfOrder},
+	{service.ErrConflict, http.StatusConflict, codeConflict},
+	{service.ErrPreconditionFailed, http.StatusPreconditionFailed, codePreconditionFailed},
+	{service.ErrValidation, http.StatusUnprocessableEntity, codeValidation},
+	{service.ErrUnauthorized, http.StatusUnauthorized, codeUnauthorized},
+	{service.ErrForbidden, http.StatusForbidden, codeForbidden},
+	{service.ErrRetryable, http.StatusServiceUnavailable, codeTxConflict},
+}
+
+// sendError отвечает на ошибку сервисного слоя.
+// Для известных ошибок клиент получает их сообщение, для прочих — 500 и message.
+func sendError(ctx *gin.Context, err error, message string) {
+	if sendContextError(ctx, err) {
+		return
+	}
+	for _, e := range serviceErrors {
+		if !errors.Is(err, e.kind) {
+			continue
+		}
+		resp := Response{
+			Message: message,
+			Code:    e.code,
+		}
+		var serviceErr *service.Error
+		if errors.As(err, &serviceErr) {
+			resp.Message = serviceErr.Message
+		}
+		if errors.Is(err, service.ErrRetryable) {
+			ctx.Header("Retry-After", retryAfter)
+		}
+		resp.SendError(ctx, err, e.status)
+		return
+	}
+
+	resp := Response{
+		Message: message,
+	}
+	resp.SendError(ctx, err, http.StatusInternalServerError)
+}
+
+// sendContextError отвечает на прерванные по контексту запросы: 504, если истёк срок
+// обработки запроса, 503, если не уложился отдельный запрос к БД, 499, если клиент отключился
+func sendContextError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(ctx.Request.Context().Err(), context.DeadlineExceeded):
+		resp := Response{
+			Message: "Превышено время обработки запроса",
+		}
+		resp.SendError(ctx, err, http.StatusGatewayTimeout)
+	case errors.Is(err, context.DeadlineExceeded):
+		resp := Response{
+			Message: "База данных не ответила вовремя, повторите запрос позже",
+		}
+		resp.SendError(ctx, err, http.StatusServiceUnavailable)
+	case errors.Is(err, context.Canceled):
+		resp := Response{
+			Message: "Запрос отменён клиентом",
+		}
+		resp.SendError(ctx, err, statusClientClosedRequest)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"quest_service/internal/service"
+	"testing"
+	"time"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// sendTestError вызывает sendError в контексте запроса reqCtx и возвращает статус и тело ответа
+func sendTestError(t *testing.T, reqCtx context.Context, err error) (int, Response) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx)
+
+	sendError(ctx, err, "Запасное сообщение")
+
+	var resp Response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return recorder.Code, resp
+}
+
+func TestSendErrorServiceErrors(t *testing.T) {
+	tests := []struct {
+		kind   error
+		status int
+		code   string
+	}{
+		{service.ErrNotFound, http.StatusNotFound, codeNotFound},
+		{service.ErrAlreadyCompleted, http.StatusConflict, codeAlreadyCompleted},
+		{service.ErrConflict, http.StatusConflict, codeConflict},
+		{service.ErrDeleted, http.StatusGone, codeDeleted},
+		{service.ErrOutsideWindow, http.StatusConflict, codeOutsideWindow},
+		{service.ErrQuestInactive, http.StatusConflict, codeQuestInactive},
+		{service.ErrQuestLocked, http.StatusConflict, codeQuestLocked},
+		{service.ErrOutOfOrder, http.StatusConflict, codeOutOfOrder},
+		{service.ErrPreconditionFailed, http.StatusPreconditionFailed, codePreconditionFailed},
+		{service.ErrValidation, http.StatusUnprocessableEntity, codeValidation},
+		{service.ErrUnauthorized, http.StatusUnauthorized, codeUnauthorized},
+		{service.ErrForbidden, http.StatusForbidden, codeForbidden},
+		{service.ErrRetryable, http.StatusServiceUnavailable, codeTxConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", &service.Error{Kind: tt.kind, Message: "Сообщение сервиса"})
+			status, resp := sendTestError(t, context.Background(), err)
+			if status != tt.status || resp.Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q", status, resp.Code, tt.status, tt.code)
+			}
+			if resp.Message != "Сообщение сервиса" {
+				t.Fatalf("message = %q, want service message", resp.Message)
+			}
+		})
+	}
+}
+
+func TestSendErrorUnknown(t *testing.T) {
+	status, resp := sendTestError(t, context.Background(), errors.New("boom"))
+	if status != http.StatusInternalServerError || resp.Code != codeInternal {
+		t.Fatalf("got %d %q, want %d %q", status, resp.Code, http.StatusInternalServerError, codeInternal)
+	}
+	if resp.Message != "Запасное сообщение" {
+		t.Fatalf("message = %q, want fallback message", resp.Message)
+	}
+}
+
+// Не разрешившийся конфликт транзакций — 503 с Retry-After, а не 500
+func TestSendErrorRetryable(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	sendError(ctx, fmt.Errorf("tx: %w", service.ErrRetryable), "Запасное сообщение")
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != retryAfter {
+		t.Fatalf("Retry-After = %q, want %q", got, retryAfter)
+	}
+}
+
+func TestSendErrorContext(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		reqCtx context.Context
+		err    error
+		status int
+		code   string
+	}{
+		{"истёк срок запроса", expired, context.DeadlineExceeded, http.StatusGatewayTimeout, codeRequestTimeout},
+		{"таймаут БД", context.Background(), fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, codeDBTimeout},
+		{"клиент отключился", context.Background(), fmt.Errorf("query: %w", context.Canceled), statusClientClosedRequest, codeClientClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := sendTestError(t, tt.reqCtx, tt.err)
+			if status != tt.status || resp.Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q", status, resp.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
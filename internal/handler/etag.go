@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"quest_service/internal/entity"
+	"strconv"
+	"strings"
+)
+
+const (
+	etagHeader        = "ETag"
+	ifMatchHeader     = "If-Match"
+	ifNoneMatchHeader = "If-None-Match"
+)
+
+// versionETag возвращает ETag записи по её версии
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion возвращает версию из заголовка If-Match. Заголовок обязателен для изменения
+// квестов и заданий: без него отвечает 428, при неверном формате — 400.
+// "*" снимает проверку версии
+func ifMatchVersion(ctx *gin.Context) (int, bool) {
+	header := strings.TrimSpace(ctx.GetHeader(ifMatchHeader))
+	if header == "" {
+		resp := Response{
+			Message: "Требуется заголовок If-Match с ETag записи",
+		}
+		resp.Send(ctx, http.StatusPreconditionRequired)
+		return 0, false
+	}
+	if header == "*" {
+		return entity.AnyVersion, true
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version < 1 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		resp := Response{
+			Message: "Неверный заголовок If-Match",
+		}
+		resp.Send(ctx, http.StatusBadRequest)
+		return 0, false
+	}
+	return version, true
+}
+
+// notModified выставляет ETag и отвечает 304, если клиент прислал его же в If-None-Match
+func notModified(ctx *gin.Context, etag string) bool {
+	ctx.Header(etagHeader, etag)
+	if !etagMatches(ctx.GetHeader(ifNoneMatchHeader), etag) {
+		return false
+	}
+	ctx.Status(http.StatusNotModified)
+	return true
+}
+
+// etagMatches сравнивает ETag со списком из If-None-Match (слабое сравнение)
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"quest_service/internal/entity"
+	"testing"
+)
+
+func TestVersionETag(t *testing.T) {
+	if got := versionETag(12); got != `"12"` {
+		t.Fatalf(`versionETag(12) = %s, want "12"`, got)
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		wantVersion int
+		wantOK      bool
+		wantStatus  int
+	}{
+		{"без заголовка", "", 0, false, http.StatusPreconditionRequired},
+		{"версия", `"3"`, 3, true, http.StatusOK},
+		{"пробелы вокруг", ` "3" `, 3, true, http.StatusOK},
+		{"любая версия", "*", entity.AnyVersion, true, http.StatusOK},
+		{"без кавычек", "3", 0, false, http.StatusBadRequest},
+		{"одна кавычка", `"3`, 0, false, http.StatusBadRequest},
+		{"слабый ETag", `W/"3"`, 0, false, http.StatusBadRequest},
+		{"нулевая версия", `"0"`, 0, false, http.StatusBadRequest},
+		{"не число", `"abc"`, 0, false, http.StatusBadRequest},
+		{"список", `"3", "4"`, 0, false, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.header != "" {
+				ctx.Request.Header.Set(ifMatchHeader, tt.header)
+			}
+
+			version, ok := ifMatchVersion(ctx)
+			if version != tt.wantVersion || ok != tt.wantOK {
+				t.Fatalf("ifMatchVersion() = %d, %v, want %d, %v", version, ok, tt.wantVersion, tt.wantOK)
+			}
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`"5"`, true},
+		{`W/"5"`, true},
+		{`"4"`, false},
+		{`"4", "5"`, true},
+		{`"4",W/"5"`, true},
+		{"*", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := etagMatches(tt.header, `"5"`); got != tt.want {
+				t.Fatalf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`"5"`, true},
+		{`"4"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			ctx.Request.Header.Set(ifNoneMatchHeader, tt.header)
+
+			if got := notModified(ctx, `"5"`); got != tt.want {
+				t.Fatalf("notModified() = %v, want %v", got, tt.want)
+			}
+			ctx.Writer.WriteHeaderNow()
+			if recorder.Header().Get(etagHeader) != `"5"` {
+				t.Fatalf("ETag = %q, want \"5\"", recorder.Header().Get(etagHeader))
+			}
+			if tt.want && recorder.Code != http.StatusNotModified {
+				t.Fatalf("status = %d, want 304", recorder.Code)
+			}
+		})
+	}
+}
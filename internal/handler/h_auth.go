@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"quest_service/internal/entity"
+)
+
+// @Summary		Регистрация
+// @Tags			auth
+// @Description	Регистрация пользователя по имени и паролю
+// @ID				post-auth-sign-up
+// @Accept			json
+// @Produce		json
+// @Param			input			body		entity.SignUpInput	true	"body"
+// @Success		201				{object}	Response
+// @Failure		400,409,422		{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/auth/sign-up [post]
+func (h *Handler) SignUp(ctx *gin.Context) {
+	var input entity.SignUpInput
+	// Получение тела запроса
+	if err := ctx.BindJSON(&input); err != nil {
+		resp := Response{
+			Message: "Неверное тело запроса",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Валидация
+	if err := input.Validate(); err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 422)
+		return
+	}
+	// Регистрация пользователя
+	userID, err := h.services.Authorization.SignUp(ctx.Request.Context(), &input)
+	if err != nil {
+		sendError(ctx, err, "Не удалось зарегистрировать пользователя")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Пользователь успешно зарегистрирован",
+		Details: entity.User{
+			ID:       userID,
+			UserName: input.UserName,
+		},
+	}
+	resp.Send(ctx, 201)
+}
+
+// @Summary		Вход
+// @Tags			auth
+// @Description	Вход по имени и паролю. Возвращает access- и refresh-токены
+// @ID				post-auth-sign-in
+// @Accept			json
+// @Produce		json
+// @Param			input			body		entity.SignInInput	true	"body"
+// @Success		200				{object}	Response
+// @Failure		400,401,422		{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/auth/sign-in [post]
+func (h *Handler) SignIn(ctx *gin.Context) {
+	var input entity.SignInInput
+	// Получение тела запроса
+	if err := ctx.BindJSON(&input); err != nil {
+		resp := Response{
+			Message: "Неверное тело запроса",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Валидация
+	if err := input.Validate(); err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 422)
+		return
+	}
+	// Выпуск токенов
+	tokens, err := h.services.Authorization.SignIn(ctx.Request.Context(), &input)
+	if err != nil {
+		sendError(ctx, err, "Не удалось выполнить вход")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Вход выполнен",
+		Details: tokens,
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Обновление токенов
+// @Tags			auth
+// @Description	Выпуск новой пары токенов по refresh-токену
+// @ID				post-auth-refresh
+// @Accept			json
+// @Produce		json
+// @Param			input			body		entity.RefreshInput	true	"body"
+// @Success		200				{object}	Response
+// @Failure		400,401,422		{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/auth/refresh [post]
+func (h *Handler) Refresh(ctx *gin.Context) {
+	var input entity.RefreshInput
+	// Получение тела запроса
+	if err := ctx.BindJSON(&input); err != nil {
+		resp := Response{
+			Message: "Неверное тело запроса",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Валидация
+	if err := input.Validate(); err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 422)
+		return
+	}
+	// Выпуск токенов
+	tokens, err := h.services.Authorization.Refresh(ctx.Request.Context(), input.RefreshToken)
+	if err != nil {
+		sendError(ctx, err, "Не удалось обновить токены")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Токены обновлены",
+		Details: tokens,
+	}
+	resp.Send(ctx, 200)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"quest_service/internal/buildinfo"
+)
+
+const codeNotReady = "not_ready"
+
+// Liveness отвечает, пока процесс жив. Зависимости не проверяются
+func (h *Handler) Liveness(ctx *gin.Context) {
+	resp := Response{
+		Message: "ok",
+	}
+	resp.Send(ctx, 200)
+}
+
+// Readiness отвечает 200, если БД доступна, схема актуальна и сервис не останавливается
+func (h *Handler) Readiness(ctx *gin.Context) {
+	readiness := h.services.Health.Ready(ctx.Request.Context())
+	if !readiness.Ready {
+		resp := Response{
+			Message: "Сервис не готов принимать запросы",
+			Code:    codeNotReady,
+			Details: readiness,
+		}
+		resp.Send(ctx, 503)
+		return
+	}
+	resp := Response{
+		Message: "ok",
+		Details: readiness,
+	}
+	resp.Send(ctx, 200)
+}
+
+// Version возвращает версию, коммит и время сборки
+func (h *Handler) Version(ctx *gin.Context) {
+	resp := Response{
+		Message: "Сведения о сборке",
+		Details: buildinfo.Get(),
+	}
+	resp.Send(ctx, 200)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"quest_service/internal/entity"
+	"quest_service/internal/service"
+	"testing"
+)
+
+type fakeHealthService struct {
+	readiness entity.Readiness
+}
+
+func (s *fakeHealthService) Ready(context.Context) *entity.Readiness {
+	return &s.readiness
+}
+
+func (s *fakeHealthService) StartDraining() {}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name   string
+		ready  bool
+		status int
+		code   string
+	}{
+		{"готов", true, http.StatusOK, ""},
+		{"не готов", false, http.StatusServiceUnavailable, codeNotReady},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{services: &service.Service{Health: &fakeHealthService{readiness: entity.Readiness{Ready: tt.ready}}}}
+			recorder := httptest.NewRecorder()
+			h.InitRoutes().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			var resp Response
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if recorder.Code != tt.status || resp.Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q", recorder.Code, resp.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
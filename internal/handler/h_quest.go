@@ -2,7 +2,7 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
-	"log"
+	"log/slog"
 	"quest_service/internal/entity"
 	"strconv"
 	"time"
@@ -10,14 +10,15 @@ import (
 
 // @Summary		Создание квеста
 // @Tags			quests
-// @Description	Создание квеста. В квесте может быть несколько задач. Каждая задача может быть выполнена один или несколько раз в квесте - зависит от параметра is_reusable.
+// @Description	Создание квеста. В квесте может быть несколько задач. Каждая задача может быть выполнена один или несколько раз в квесте - зависит от параметра is_reusable. Квест создаётся черновиком и виден игрокам после публикации.
 // @ID				post-quests
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			input			body		entity.QuestInput	true	"body"
 // @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/quests/ [post]
 func (h *Handler) CreateQuest(ctx *gin.Context) {
@@ -36,16 +37,13 @@ func (h *Handler) CreateQuest(ctx *gin.Context) {
 		resp := Response{
 			Message: err.Error(),
 		}
-		resp.Send(ctx, 400)
+		resp.Send(ctx, 422)
 		return
 	}
 	// Создание квеста
-	_, err = h.services.Quest.CreateQuest(&input)
+	_, err = h.services.Quest.CreateQuest(ctx.Request.Context(), &input)
 	if err != nil {
-		resp := Response{
-			Message: "Не удалось создать квест",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось создать квест")
 		return
 	}
 	// Отправка ответа
@@ -58,58 +56,128 @@ func (h *Handler) CreateQuest(ctx *gin.Context) {
 
 // @Summary		Получить квесты
 // @Tags			quests
-// @Description	Получить квесты
+// @Description	Получить страницу квестов с заданиями. Следующая страница запрашивается по next_cursor с теми же параметрами сортировки. Квесты вне окна доступности (starts_at, ends_at) видит только администратор, неопубликованные — редакторы
 // @ID				get-quests
 // @Accept			json
 // @Produce		json
-// @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Security		ApiKeyAuth
+// @Param			limit				query		int		false	"Количество квестов (по умолчанию 20, максимум 100)"
+// @Param			cursor				query		string	false	"Курсор следующей страницы"
+// @Param			min_cost			query		int		false	"Минимальная стоимость"
+// @Param			max_cost			query		int		false	"Максимальная стоимость"
+// @Param			name				query		string	false	"Подстрока названия"
+// @Param			has_reusable_tasks	query		bool	false	"Есть ли в квесте повторяемые задания"
+// @Param			status				query		string	false	"Статус квеста"	Enums(draft, published, paused, archived)
+// @Param			sort				query		string	false	"Поле сортировки"	Enums(cost, created_at, name)
+// @Param			order				query		string	false	"Направление сортировки"	Enums(asc, desc)
+// @Param			If-None-Match		header		string	false	"ETag предыдущего ответа"
+// @Success		200				{object}	Response{details=entity.QuestPage}
+// @Header			200				{string}	ETag	"Хеш ответа"
+// @Success		304
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/quests/ [get]
 func (h *Handler) GetQuests(ctx *gin.Context) {
-	// Получение квестов и их заданий
-	quests, err := h.services.Quest.GetQuestsAndTasks()
+	// Получение параметров выборки
+	filter, err := getQuestFilter(ctx)
 	if err != nil {
 		resp := Response{
-			Message: "Не удалось получить квесты",
+			Message: err.Error(),
 		}
-		resp.SendError(ctx, err, 500)
+		resp.Send(ctx, 400)
+		return
+	}
+	filter.Visibility = questVisibility(ctx)
+	// Получение квестов и их заданий
+	page, err := h.services.Quest.GetQuests(ctx.Request.Context(), filter)
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить квесты")
 		return
 	}
 	// Отправка ответа
 	resp := Response{
 		Message: "Квесты",
-		Details: quests,
+		Details: page,
 	}
-	resp.Send(ctx, 200)
+	resp.SendCached(ctx, 200)
 	return
 }
 
+// @Summary		Получить квест
+// @Tags			quests
+// @Description	Получить квест с заданиями. Квест вне окна доступности для всех, кроме администратора, и неопубликованный квест для игроков не найдены (404)
+// @ID				get-quests-id
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID квеста"
+// @Param			If-None-Match	header		string	false	"ETag полученного ранее квеста"
+// @Success		200				{object}	Response{details=entity.Quest}
+// @Header			200				{string}	ETag	"Версия квеста"
+// @Success		304
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/quests/{id} [get]
+func (h *Handler) GetQuest(ctx *gin.Context) {
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID квеста",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Получение квеста
+	quest, err := h.services.Quest.GetQuest(ctx.Request.Context(), questID, questVisibility(ctx))
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить квест")
+		return
+	}
+	if notModified(ctx, versionETag(quest.Version)) {
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Квест",
+		Details: quest,
+	}
+	resp.Send(ctx, 200)
+}
+
 // @Summary		Обновление квеста
 // @Tags			quests
-// @Description	Обновление квеста
-// @ID				put-quests
+// @Description	Частичное обновление квеста: меняются только переданные поля. Возвращает обновлённый квест. Границы окна starts_at и ends_at очищаются явным null
+// @ID				patch-quests
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			id				path		int					true	"ID квеста"
-// @Param			input			body		entity.QuestInputForUpdate	true	"body"
-// @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Param			If-Match		header		string				true	"ETag квеста или *"
+// @Param			input			body		entity.QuestPatch	true	"body"
+// @Success		200				{object}	Response{details=entity.Quest}
+// @Header			200				{string}	ETag	"Новая версия квеста"
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
-// @Router			/quests/{id} [put]
+// @Router			/quests/{id} [patch]
 func (h *Handler) UpdateQuest(ctx *gin.Context) {
 	// Получение questID из параметров запроса
 	questID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		resp := Response{
-			Message: "Неверный ID квеста",
+			Message: "Неверный ID квеста",
 		}
 		resp.SendError(ctx, err, 400)
 		return
 	}
-	var input entity.QuestInput
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+	var input entity.QuestPatch
 	// Получение тела запроса
 	if err := ctx.BindJSON(&input); err != nil {
 		resp := Response{
@@ -119,27 +187,25 @@ func (h *Handler) UpdateQuest(ctx *gin.Context) {
 		return
 	}
 	// Валидация
-	err = input.ValidateForUpdate()
+	err = input.Validate()
 	if err != nil {
 		resp := Response{
 			Message: err.Error(),
 		}
-		resp.Send(ctx, 400)
+		resp.Send(ctx, 422)
 		return
 	}
-	log.Printf("input: %v", input)
 	// Обновление квеста
-	err = h.services.Quest.UpdateQuest(questID, &input)
+	quest, err := h.services.Quest.UpdateQuest(ctx.Request.Context(), questID, version, &input)
 	if err != nil {
-		resp := Response{
-			Message: "Не удалось обновить квест",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось обновить квест")
 		return
 	}
+	ctx.Header(etagHeader, versionETag(quest.Version))
 	// Отправка ответа
 	resp := Response{
 		Message: "Квест успешно обновлен",
+		Details: quest,
 	}
 	resp.Send(ctx, 200)
 	return
@@ -151,10 +217,12 @@ func (h *Handler) UpdateQuest(ctx *gin.Context) {
 // @ID				delete-quests
 // @Accept			json
 // @Produce		json
-// @Param			id				path		int	true	"ID квеста"
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID квеста"
+// @Param			If-Match		header		string	true	"ETag квеста или *"
 // @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/quests/{id} [delete]
 func (h *Handler) DeleteQuest(ctx *gin.Context) {
@@ -167,13 +235,14 @@ func (h *Handler) DeleteQuest(ctx *gin.Context) {
 		resp.SendError(ctx, err, 400)
 		return
 	}
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
 	// Удаление квеста
-	err = h.services.Quest.DeleteQuest(questID)
+	err = h.services.Quest.DeleteQuest(ctx.Request.Context(), questID, version)
 	if err != nil {
-		resp := Response{
-			Message: "Не удалось удалить квест",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось удалить квест")
 		return
 	}
 	// Отправка ответа
@@ -186,26 +255,24 @@ func (h *Handler) DeleteQuest(ctx *gin.Context) {
 
 // @Summary		Создание тестовых данных
 // @Tags			quests
-// @Description	Создание тестовых данных
+// @Description	Создание 1000 опубликованных тестовых квестов одной транзакцией
 // @ID				post-quests-test
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/quests/test [post]
 func (h *Handler) CreateTestQuestData(ctx *gin.Context) {
 	timeStart := time.Now()
-	err := h.services.Quest.CreateTestQuestData()
+	err := h.services.Quest.CreateTestQuestData(ctx.Request.Context())
 	if err != nil {
-		resp := Response{
-			Message: "Не удалось создать тестовые данные",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось создать тестовые данные")
 		return
 	}
-	log.Printf("Время выполнения: %v", time.Since(timeStart))
+	slog.InfoContext(ctx.Request.Context(), "Тестовые данные созданы", "duration", time.Since(timeStart))
 	// Отправка ответа
 	resp := Response{
 		Message: "Тестовые данные успешно созданы",
@@ -214,3 +281,66 @@ func (h *Handler) CreateTestQuestData(ctx *gin.Context) {
 	return
 
 }
+
+// @Summary		Порядок заданий квеста
+// @Tags			quests
+// @Description	Задать порядок заданий квеста одним запросом. Список должен содержать каждое задание квеста ровно один раз, иначе 422. Возвращает квест с заданиями в новом порядке
+// @ID				put-quests-id-tasks-order
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int						true	"ID квеста"
+// @Param			If-Match		header		string					true	"ETag квеста или *"
+// @Param			input			body		entity.TaskOrderInput	true	"body"
+// @Success		200				{object}	Response{details=entity.Quest}
+// @Header			200				{string}	ETag	"Новая версия квеста"
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/quests/{id}/tasks/order [put]
+func (h *Handler) ReorderTasks(ctx *gin.Context) {
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID квеста",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+	var input entity.TaskOrderInput
+	// Получение тела запроса
+	if err := ctx.BindJSON(&input); err != nil {
+		resp := Response{
+			Message: "Неверное тело запроса",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Валидация
+	err = input.Validate()
+	if err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 422)
+		return
+	}
+	// Изменение порядка заданий
+	quest, err := h.services.Quest.ReorderTasks(ctx.Request.Context(), questID, version, &input)
+	if err != nil {
+		sendError(ctx, err, "Не удалось изменить порядок заданий")
+		return
+	}
+	ctx.Header(etagHeader, versionETag(quest.Version))
+	// Отправка ответа
+	resp := Response{
+		Message: "Порядок заданий изменён",
+		Details: quest,
+	}
+	resp.Send(ctx, 200)
+}
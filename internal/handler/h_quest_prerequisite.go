@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"quest_service/internal/entity"
+	"strconv"
+)
+
+// @Summary		Квесты-условия
+// @Tags			quests
+// @Description	Квесты, которые нужно завершить, чтобы открыть квест. Удалённые квесты-условия не учитываются
+// @ID				get-quests-id-prerequisites
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID квеста"
+// @Success		200				{object}	Response{details=entity.QuestPrerequisites}
+// @Header			200				{string}	ETag	"Версия квеста"
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/quests/{id}/prerequisites [get]
+func (h *Handler) GetPrerequisites(ctx *gin.Context) {
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID квеста",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Получение квестов-условий
+	prerequisites, err := h.services.Quest.GetPrerequisites(ctx.Request.Context(), questID, questVisibility(ctx))
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить квесты-условия")
+		return
+	}
+	ctx.Header(etagHeader, versionETag(prerequisites.Version))
+	// Отправка ответа
+	resp := Response{
+		Message: "Квесты-условия",
+		Details: prerequisites,
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Изменение квестов-условий
+// @Tags			quests
+// @Description	Заменить квесты-условия квеста. Пустой список снимает условия. Связи, замыкающие цепочку в цикл, отклоняются (422)
+// @ID				put-quests-id-prerequisites
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int								true	"ID квеста"
+// @Param			If-Match		header		string							true	"ETag квеста или *"
+// @Param			input			body		entity.QuestPrerequisitesInput	true	"body"
+// @Success		200				{object}	Response{details=entity.QuestPrerequisites}
+// @Header			200				{string}	ETag	"Новая версия квеста"
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/quests/{id}/prerequisites [put]
+func (h *Handler) SetPrerequisites(ctx *gin.Context) {
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID квеста",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+	var input entity.QuestPrerequisitesInput
+	// Получение тела запроса
+	if err := ctx.BindJSON(&input); err != nil {
+		resp := Response{
+			Message: "Неверное тело запроса",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Валидация
+	err = input.Validate(questID)
+	if err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 422)
+		return
+	}
+	// Замена квестов-условий
+	prerequisites, err := h.services.Quest.SetPrerequisites(ctx.Request.Context(), questID, version, &input)
+	if err != nil {
+		sendError(ctx, err, "Не удалось изменить квесты-условия")
+		return
+	}
+	ctx.Header(etagHeader, versionETag(prerequisites.Version))
+	// Отправка ответа
+	resp := Response{
+		Message: "Квесты-условия обновлены",
+		Details: prerequisites,
+	}
+	resp.Send(ctx, 200)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"quest_service/internal/entity"
+	"strconv"
+)
+
+// @Summary		Публикация квеста
+// @Tags			quests
+// @Description	Открыть квест игрокам. Публикуются черновики и приостановленные квесты, иначе 409
+// @ID				post-quests-id-publish
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID квеста"
+// @Param			If-Match		header		string	true	"ETag квеста или *"
+// @Success		200				{object}	Response{details=entity.Quest}
+// @Header			200				{string}	ETag	"Новая версия квеста"
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/quests/{id}/publish [post]
+func (h *Handler) PublishQuest(ctx *gin.Context) {
+	h.changeQuestStatus(ctx, entity.QuestStatusPublished, "Квест опубликован")
+}
+
+// @Summary		Приостановка квеста
+// @Tags			quests
+// @Description	Скрыть опубликованный квест от игроков и перестать принимать выполнения. Приостанавливаются только опубликованные квесты, иначе 409
+// @ID				post-quests-id-pause
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID квеста"
+// @Param			If-Match		header		string	true	"ETag квеста или *"
+// @Success		200				{object}	Response{details=entity.Quest}
+// @Header			200				{string}	ETag	"Новая версия квеста"
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/quests/{id}/pause [post]
+func (h *Handler) PauseQuest(ctx *gin.Context) {
+	h.changeQuestStatus(ctx, entity.QuestStatusPaused, "Квест приостановлен")
+}
+
+// @Summary		Архивирование квеста
+// @Tags			quests
+// @Description	Навсегда закрыть квест: история выполнений сохраняется, новые выполнения не принимаются. Архивный квест повторно не архивируется (409)
+// @ID				post-quests-id-archive
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID квеста"
+// @Param			If-Match		header		string	true	"ETag квеста или *"
+// @Success		200				{object}	Response{details=entity.Quest}
+// @Header			200				{string}	ETag	"Новая версия квеста"
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/quests/{id}/archive [post]
+func (h *Handler) ArchiveQuest(ctx *gin.Context) {
+	h.changeQuestStatus(ctx, entity.QuestStatusArchived, "Квест перенесён в архив")
+}
+
+// changeQuestStatus переводит квест из пути запроса в статус status
+func (h *Handler) changeQuestStatus(ctx *gin.Context, status, message string) {
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID квеста",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+	// Смена статуса квеста
+	quest, err := h.services.Quest.ChangeQuestStatus(ctx.Request.Context(), questID, version, status)
+	if err != nil {
+		sendError(ctx, err, "Не удалось изменить статус квеста")
+		return
+	}
+	ctx.Header(etagHeader, versionETag(quest.Version))
+	// Отправка ответа
+	resp := Response{
+		Message: message,
+		Details: quest,
+	}
+	resp.Send(ctx, 200)
+}
@@ -8,14 +8,15 @@ import (
 
 // @Summary		Завершение задачи
 // @Tags			tasks
-// @Description	Завершение задачи. Задача может быть выполнена несколько раз - зависит от параметра is_reusable.
+// @Description	Завершение задачи. Задача может быть выполнена несколько раз - зависит от параметра is_reusable. Вне окна доступности квеста выполнение отклоняется (409, code=outside_window), в приостановленном и архивном квесте — (409, code=quest_inactive), до завершения квестов-условий — (409, code=quest_locked), в последовательном квесте до выполнения предыдущих заданий и до выполнения зависимостей — (409, code=out_of_order).
 // @ID				post-tasks-progress
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			input			body		entity.TaskProgress	true	"body"
 // @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Failure		400,401,403,404,409,410,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/task-progress/ [post]
 func (h *Handler) TaskCompletion(ctx *gin.Context) {
@@ -28,49 +29,91 @@ func (h *Handler) TaskCompletion(ctx *gin.Context) {
 		resp.SendError(ctx, err, 400)
 		return
 	}
+	// Задание выполняет аутентифицированный пользователь
+	userID, err := getUserID(ctx)
+	if err != nil {
+		sendError(ctx, err, "Не удалось определить пользователя")
+		return
+	}
+	input.UserID = userID
 	// Валидация
-	err := input.Validate()
+	err = input.Validate()
 	if err != nil {
 		resp := Response{
 			Message: err.Error(),
 		}
-		resp.Send(ctx, 400)
+		resp.Send(ctx, 422)
 		return
 	}
 	// Завершение задания
-	err = h.services.Task.TaskCompletion(&input)
+	result, err := h.services.Task.TaskCompletion(ctx.Request.Context(), &input)
 	if err != nil {
-		if err.Error() == "Вы уже выполнили это задание" || err.Error() == "Задание не найдено" {
-			resp := Response{
-				Message: err.Error(),
-			}
-			resp.Send(ctx, 200)
-			return
-		}
-		resp := Response{
-			Message: "Не удалось завершить задание",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось завершить задание")
 		return
 	}
 	// Отправка ответа
 	resp := Response{
 		Message: "Задание успешно завершено",
+		Details: result,
 	}
 	resp.Send(ctx, 200)
 	return
 }
 
+// @Summary		Получить задание
+// @Tags			tasks
+// @Description	Получить задание. Задание скрытого от пользователя квеста не найдено (404)
+// @ID				get-tasks-id
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID задания"
+// @Param			If-None-Match	header		string	false	"ETag полученного ранее задания"
+// @Success		200				{object}	Response{details=entity.Task}
+// @Header			200				{string}	ETag	"Версия задания"
+// @Success		304
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/tasks/{id} [get]
+func (h *Handler) GetTask(ctx *gin.Context) {
+	// Получение taskID из параметров запроса
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID задания",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Получение задания
+	task, err := h.services.Task.GetTask(ctx.Request.Context(), taskID, questVisibility(ctx))
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить задание")
+		return
+	}
+	if notModified(ctx, versionETag(task.Version)) {
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Задание",
+		Details: task,
+	}
+	resp.Send(ctx, 200)
+}
+
 // @Summary		Создание задания
 // @Tags			tasks
-// @Description	Создание задания
+// @Description	Создание задания. Задание добавляется в конец квеста. depends_on — задания того же квеста, которые выполняются раньше; связи, образующие цикл, отклоняются (422). Квест не найден — 404, квест в корзине — 409
 // @ID				post-tasks
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			input			body		entity.TaskInput	true	"body"
 // @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/tasks/ [post]
 func (h *Handler) CreateTask(ctx *gin.Context) {
@@ -89,16 +132,13 @@ func (h *Handler) CreateTask(ctx *gin.Context) {
 		resp := Response{
 			Message: err.Error(),
 		}
-		resp.Send(ctx, 400)
+		resp.Send(ctx, 422)
 		return
 	}
 	// Создание задания
-	taskID, err := h.services.Task.CreateTask(&input)
+	taskID, err := h.services.Task.CreateTask(ctx.Request.Context(), &input)
 	if err != nil {
-		resp := Response{
-			Message: "Не удалось создать задание",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось создать задание")
 		return
 	}
 	// Отправка ответа
@@ -112,29 +152,36 @@ func (h *Handler) CreateTask(ctx *gin.Context) {
 
 // @Summary		Обновление задания
 // @Tags			tasks
-// @Description	Обновление задания
-// @ID				put-tasks
+// @Description	Частичное обновление задания: меняются только переданные поля. Возвращает обновлённое задание. depends_on заменяет зависимости целиком, связи, образующие цикл, отклоняются (422)
+// @ID				patch-tasks
 // @Accept			json
 // @Produce		json
-// @Param			id				path		int					true	"ID квеста"
-// @Param			input			body		entity.TaskInput	true	"body"
-// @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Security		ApiKeyAuth
+// @Param			id				path		int					true	"ID задания"
+// @Param			If-Match		header		string				true	"ETag задания или *"
+// @Param			input			body		entity.TaskPatch	true	"body"
+// @Success		200				{object}	Response{details=entity.Task}
+// @Header			200				{string}	ETag	"Новая версия задания"
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
-// @Router			/tasks/{id} [put]
+// @Router			/tasks/{id} [patch]
 func (h *Handler) UpdateTask(ctx *gin.Context) {
-	// Получение questID из параметров запроса
-	questID, err := strconv.Atoi(ctx.Param("id"))
+	// Получение taskID из параметров запроса
+	taskID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		resp := Response{
-			Message: "Неверный ID квеста",
+			Message: "Неверный ID задания",
 		}
 		resp.SendError(ctx, err, 400)
 		return
 	}
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
 	// 	Получение тела запроса
-	var input entity.TaskInput
+	var input entity.TaskPatch
 	if err = ctx.BindJSON(&input); err != nil {
 		resp := Response{
 			Message: "Неверное тело запроса",
@@ -143,26 +190,25 @@ func (h *Handler) UpdateTask(ctx *gin.Context) {
 		return
 	}
 	// Валидация
-	err = input.ValidateForUpdate()
+	err = input.Validate()
 	if err != nil {
 		resp := Response{
 			Message: err.Error(),
 		}
-		resp.Send(ctx, 400)
+		resp.Send(ctx, 422)
 		return
 	}
 	// Обновление задания
-	err = h.services.Task.UpdateTask(questID, input.Name, input.Cost, input.IsReusable)
+	task, err := h.services.Task.UpdateTask(ctx.Request.Context(), taskID, version, &input)
 	if err != nil {
-		resp := Response{
-			Message: "Не удалось обновить задание",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось обновить задание")
 		return
 	}
+	ctx.Header(etagHeader, versionETag(task.Version))
 	// Отправка ответа
 	resp := Response{
 		Message: "Задание успешно обновлено",
+		Details: task,
 	}
 	resp.Send(ctx, 200)
 	return
@@ -174,29 +220,32 @@ func (h *Handler) UpdateTask(ctx *gin.Context) {
 // @ID				delete-task
 // @Accept			json
 // @Produce		json
-// @Param			id				path		int	true	"ID задания"
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID задания"
+// @Param			If-Match		header		string	true	"ETag задания или *"
 // @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/tasks/{id} [delete]
 func (h *Handler) DeleteTask(ctx *gin.Context) {
-	// Получение questID из параметров запроса
-	questID, err := strconv.Atoi(ctx.Param("id"))
+	// Получение taskID из параметров запроса
+	taskID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		resp := Response{
-			Message: "Неверный ID квеста",
+			Message: "Неверный ID задания",
 		}
 		resp.SendError(ctx, err, 400)
 		return
 	}
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
 	// Удаление задания
-	err = h.services.Task.DeleteTask(questID)
+	err = h.services.Task.DeleteTask(ctx.Request.Context(), taskID, version)
 	if err != nil {
-		resp := Response{
-			Message: "Не удалось удалить задание",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось удалить задание")
 		return
 	}
 	// Отправка ответа
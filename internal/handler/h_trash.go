@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// @Summary		Удалённые квесты
+// @Tags			trash
+// @Description	Квесты в корзине, от недавно удалённых к давним. Задания квестов не загружаются
+// @ID				get-admin-trash-quests
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			limit			query		int	false	"Количество записей (по умолчанию 20, максимум 100)"
+// @Param			offset			query		int	false	"Смещение"
+// @Success		200				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/admin/trash/quests [get]
+func (h *Handler) GetDeletedQuests(ctx *gin.Context) {
+	// Получение параметров пагинации
+	page, err := getPage(ctx)
+	if err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 400)
+		return
+	}
+	// Получение удалённых квестов
+	quests, total, err := h.services.Trash.GetDeletedQuests(ctx.Request.Context(), page)
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить удалённые квесты")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Удалённые квесты",
+		Details: map[string]interface{}{
+			"quests": quests,
+			"total":  total,
+			"limit":  page.Limit,
+			"offset": page.Offset,
+		},
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Удалённые задания
+// @Tags			trash
+// @Description	Задания в корзине, от недавно удалённых к давним
+// @ID				get-admin-trash-tasks
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			limit			query		int	false	"Количество записей (по умолчанию 20, максимум 100)"
+// @Param			offset			query		int	false	"Смещение"
+// @Success		200				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/admin/trash/tasks [get]
+func (h *Handler) GetDeletedTasks(ctx *gin.Context) {
+	// Получение параметров пагинации
+	page, err := getPage(ctx)
+	if err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 400)
+		return
+	}
+	// Получение удалённых заданий
+	tasks, total, err := h.services.Trash.GetDeletedTasks(ctx.Request.Context(), page)
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить удалённые задания")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Удалённые задания",
+		Details: map[string]interface{}{
+			"tasks":  tasks,
+			"total":  total,
+			"limit":  page.Limit,
+			"offset": page.Offset,
+		},
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Восстановление квеста
+// @Tags			trash
+// @Description	Вернуть удалённый квест в каталог
+// @ID				post-admin-trash-quests-id-restore
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int	true	"ID квеста"
+// @Success		200				{object}	Response{details=entity.Quest}
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/admin/trash/quests/{id}/restore [post]
+func (h *Handler) RestoreQuest(ctx *gin.Context) {
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID квеста",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Восстановление квеста
+	quest, err := h.services.Trash.RestoreQuest(ctx.Request.Context(), questID)
+	if err != nil {
+		sendError(ctx, err, "Не удалось восстановить квест")
+		return
+	}
+	ctx.Header(etagHeader, versionETag(quest.Version))
+	// Отправка ответа
+	resp := Response{
+		Message: "Квест восстановлен",
+		Details: quest,
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Восстановление задания
+// @Tags			trash
+// @Description	Вернуть удалённое задание в квест. Задание удалённого квеста не восстанавливается (409)
+// @ID				post-admin-trash-tasks-id-restore
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int	true	"ID задания"
+// @Success		200				{object}	Response{details=entity.Task}
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/admin/trash/tasks/{id}/restore [post]
+func (h *Handler) RestoreTask(ctx *gin.Context) {
+	// Получение taskID из параметров запроса
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID задания",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Восстановление задания
+	task, err := h.services.Trash.RestoreTask(ctx.Request.Context(), taskID)
+	if err != nil {
+		sendError(ctx, err, "Не удалось восстановить задание")
+		return
+	}
+	ctx.Header(etagHeader, versionETag(task.Version))
+	// Отправка ответа
+	resp := Response{
+		Message: "Задание восстановлено",
+		Details: task,
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Окончательное удаление квеста
+// @Tags			trash
+// @Description	Стереть квест из корзины вместе с заданиями. Если на них ссылается история выполнений, они обезличиваются (outcome=anonymized)
+// @ID				delete-admin-trash-quests-id
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID квеста"
+// @Param			If-Match		header		string	true	"ETag квеста или *"
+// @Success		200				{object}	Response{details=entity.PurgeResult}
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/admin/trash/quests/{id} [delete]
+func (h *Handler) PurgeQuest(ctx *gin.Context) {
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID квеста",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+	// Окончательное удаление квеста
+	result, err := h.services.Trash.PurgeQuest(ctx.Request.Context(), questID, version)
+	if err != nil {
+		sendError(ctx, err, "Не удалось удалить квест")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Квест удалён окончательно",
+		Details: result,
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Окончательное удаление задания
+// @Tags			trash
+// @Description	Стереть задание из корзины. Если на него ссылается история выполнений, оно обезличивается (outcome=anonymized)
+// @ID				delete-admin-trash-tasks-id
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id				path		int		true	"ID задания"
+// @Param			If-Match		header		string	true	"ETag задания или *"
+// @Success		200				{object}	Response{details=entity.PurgeResult}
+// @Failure		400,401,403,404,409,412,422,428	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/admin/trash/tasks/{id} [delete]
+func (h *Handler) PurgeTask(ctx *gin.Context) {
+	// Получение taskID из параметров запроса
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID задания",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+	// Окончательное удаление задания
+	result, err := h.services.Trash.PurgeTask(ctx.Request.Context(), taskID, version)
+	if err != nil {
+		sendError(ctx, err, "Не удалось удалить задание")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Задание удалено окончательно",
+		Details: result,
+	}
+	resp.Send(ctx, 200)
+}
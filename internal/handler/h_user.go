@@ -12,10 +12,11 @@ import (
 // @ID				post-users
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			input			body		entity.UserInput	true	"body"
 // @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/users/ [post]
 func (h *Handler) CreateUser(ctx *gin.Context) {
@@ -33,23 +34,13 @@ func (h *Handler) CreateUser(ctx *gin.Context) {
 		resp := Response{
 			Message: err.Error(),
 		}
-		resp.Send(ctx, 400)
+		resp.Send(ctx, 422)
 		return
 	}
 	// Создание пользователя
-	userID, err := h.services.User.CreateUser(&input)
+	userID, err := h.services.User.CreateUser(ctx.Request.Context(), &input)
 	if err != nil {
-		if err.Error() == "pq: duplicate key value violates unique constraint \"users_username_key\"" {
-			resp := Response{
-				Message: "Пользователь с таким именем уже существует",
-			}
-			resp.Send(ctx, 409)
-			return
-		}
-		resp := Response{
-			Message: "Не удалось создать пользователя",
-		}
-		resp.SendError(ctx, err, 500)
+		sendError(ctx, err, "Не удалось создать пользователя")
 		return
 	}
 	// Отправка ответа
@@ -64,44 +55,374 @@ func (h *Handler) CreateUser(ctx *gin.Context) {
 	return
 }
 
+// @Summary		Получить профиль пользователя
+// @Tags			users
+// @Description	Получить профиль пользователя
+// @ID				get-users-id
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			user_id			path		int	true	"user_id"
+// @Success		200				{object}	Response{details=entity.User}
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/users/{user_id} [get]
+func (h *Handler) GetUser(ctx *gin.Context) {
+	// Данные другого пользователя доступны только администратору
+	userID, ok := getTargetUserID(ctx)
+	if !ok {
+		return
+	}
+	// Получение профиля
+	user, err := h.services.User.GetUser(ctx.Request.Context(), userID)
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить пользователя")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Пользователь",
+		Details: user,
+	}
+	resp.Send(ctx, 200)
+}
+
 // @Summary		Получить баланс пользователя
 // @Tags			users
 // @Description	Получить баланс пользователя
 // @ID				get-users-id-balance
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			user_id			path		int	true	"user_id"
 // @Success		200				{object}	Response
-// @Failure		400,401,403,404	{object}	Response
-// @Failure		500				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
 // @Failure		default			{object}	Response
 // @Router			/users/{user_id}/balance [get]
 func (h *Handler) GetBalance(ctx *gin.Context) {
-	// Получение userID из параметров запроса
-	userID, err := strconv.Atoi(ctx.Param("id"))
+	// Данные другого пользователя доступны только администратору
+	userID, ok := getTargetUserID(ctx)
+	if !ok {
+		return
+	}
+	// Получение баланса пользователя
+	balance, tasks, err := h.services.User.GetBalanceAndHistoryTasks(ctx.Request.Context(), userID)
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить баланс пользователя")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Баланс пользователя",
+		Details: map[string]interface{}{
+			"balance": balance,
+			"tasks":   tasks,
+		},
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Получить прогресс пользователя по квестам
+// @Tags			users
+// @Description	Количество выполненных заданий, процент, факт завершения и блокировка квестами-условиями (is_locked) по каждому видимому квесту. Неопубликованные квесты и квесты вне окна видны только при правах quests:manage и quests:read_hidden
+// @ID				get-users-id-quests
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			user_id			path		int	true	"user_id"
+// @Param			limit			query		int	false	"Количество записей (по умолчанию 20, максимум 100)"
+// @Param			offset			query		int	false	"Смещение"
+// @Success		200				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/users/{user_id}/quests [get]
+func (h *Handler) GetUserQuests(ctx *gin.Context) {
+	// Данные другого пользователя доступны только администратору
+	userID, ok := getTargetUserID(ctx)
+	if !ok {
+		return
+	}
+	// Получение параметров пагинации
+	page, err := getPage(ctx)
 	if err != nil {
 		resp := Response{
-			Message: "Неверный ID пользователя",
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 400)
+		return
+	}
+	// Получение прогресса
+	progress, total, err := h.services.Quest.GetQuestsProgress(ctx.Request.Context(), userID, page, questVisibility(ctx))
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить прогресс по квестам")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Прогресс по квестам",
+		Details: map[string]interface{}{
+			"quests": progress,
+			"total":  total,
+			"limit":  page.Limit,
+			"offset": page.Offset,
+		},
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Получить прогресс пользователя по квесту
+// @Tags			users
+// @Description	Прогресс по квесту с состоянием каждого задания
+// @ID				get-users-id-quests-id
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			user_id			path		int	true	"user_id"
+// @Param			quest_id		path		int	true	"quest_id"
+// @Success		200				{object}	Response{details=entity.QuestProgress}
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/users/{user_id}/quests/{quest_id} [get]
+func (h *Handler) GetUserQuest(ctx *gin.Context) {
+	// Данные другого пользователя доступны только администратору
+	userID, ok := getTargetUserID(ctx)
+	if !ok {
+		return
+	}
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("questId"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID квеста",
 		}
 		resp.SendError(ctx, err, 400)
 		return
 	}
-	// Получение баланса пользователя
-	balance, tasks, err := h.services.User.GetBalanceAndHistoryTasks(userID)
+	// Получение прогресса
+	progress, err := h.services.Quest.GetQuestProgress(ctx.Request.Context(), userID, questID, questVisibility(ctx))
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить прогресс по квесту")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Прогресс по квесту",
+		Details: progress,
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Граф заданий квеста
+// @Tags			users
+// @Description	Задания квеста с зависимостями (depends_on) и прогрессом пользователя. available — задание можно выполнить сейчас: квест открыт (open), задание не выполнено или повторяемое, выполнены его зависимости, а в последовательном квесте — и предыдущие задания
+// @ID				get-users-id-quests-id-graph
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			user_id			path		int	true	"user_id"
+// @Param			quest_id		path		int	true	"quest_id"
+// @Success		200				{object}	Response{details=entity.TaskGraph}
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/users/{user_id}/quests/{quest_id}/graph [get]
+func (h *Handler) GetUserQuestGraph(ctx *gin.Context) {
+	// Данные другого пользователя доступны только администратору
+	userID, ok := getTargetUserID(ctx)
+	if !ok {
+		return
+	}
+	// Получение questID из параметров запроса
+	questID, err := strconv.Atoi(ctx.Param("questId"))
 	if err != nil {
 		resp := Response{
-			Message: "Не удалось получить баланс пользователя",
+			Message: "Неверный ID квеста",
 		}
-		resp.SendError(ctx, err, 500)
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Получение графа заданий
+	graph, err := h.services.Task.GetTaskGraph(ctx.Request.Context(), userID, questID, questVisibility(ctx))
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить граф заданий")
 		return
 	}
 	// Отправка ответа
 	resp := Response{
-		Message: "Баланс пользователя",
+		Message: "Граф заданий",
+		Details: graph,
+	}
+	resp.Send(ctx, 200)
+}
+
+// @Summary		Получить историю операций по балансу
+// @Tags			users
+// @Description	Получить проводки журнала по балансу пользователя, от новых к старым
+// @ID				get-users-id-transactions
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			user_id			path		int	true	"user_id"
+// @Param			limit			query		int	false	"Количество записей (по умолчанию 20, максимум 100)"
+// @Param			offset			query		int	false	"Смещение"
+// @Success		200				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/users/{user_id}/transactions [get]
+func (h *Handler) GetTransactions(ctx *gin.Context) {
+	// Данные другого пользователя доступны только администратору
+	userID, ok := getTargetUserID(ctx)
+	if !ok {
+		return
+	}
+	// Получение параметров пагинации
+	page, err := getPage(ctx)
+	if err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 400)
+		return
+	}
+	// Получение проводок
+	entries, total, err := h.services.User.GetTransactions(ctx.Request.Context(), userID, page)
+	if err != nil {
+		sendError(ctx, err, "Не удалось получить историю операций")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "История операций",
 		Details: map[string]interface{}{
-			"balance": balance,
-			"tasks":   tasks,
+			"transactions": entries,
+			"total":        total,
+			"limit":        page.Limit,
+			"offset":       page.Offset,
 		},
 	}
 	resp.Send(ctx, 200)
 }
+
+// @Summary		Создать проводку по балансу
+// @Tags			users
+// @Description	Ручная корректировка баланса (admin) или списание за покупку (purchase). Отрицательная сумма списывает средства.
+// @ID				post-users-id-transactions
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			user_id			path		int						true	"user_id"
+// @Param			input			body		entity.LedgerEntryInput	true	"body"
+// @Success		201				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/users/{user_id}/transactions [post]
+func (h *Handler) CreateTransaction(ctx *gin.Context) {
+	// Данные другого пользователя доступны только администратору
+	userID, ok := getTargetUserID(ctx)
+	if !ok {
+		return
+	}
+	var input entity.LedgerEntryInput
+	// Получение тела запроса
+	if err := ctx.BindJSON(&input); err != nil {
+		resp := Response{
+			Message: "Неверное тело запроса",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	input.UserID = userID
+	// Валидация
+	if err := input.Validate(); err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 422)
+		return
+	}
+	// Корректировка баланса доступна только администратору
+	identity, err := getIdentity(ctx)
+	if err != nil {
+		sendError(ctx, err, "Не удалось определить пользователя")
+		return
+	}
+	if input.Reason != entity.LedgerReasonPurchase && !identity.Can(entity.PermBalanceAdjust) {
+		resp := Response{
+			Message: "Недостаточно прав для корректировки баланса",
+		}
+		resp.Send(ctx, 403)
+		return
+	}
+	// Создание проводки
+	entry, err := h.services.User.CreateTransaction(ctx.Request.Context(), &input)
+	if err != nil {
+		sendError(ctx, err, "Не удалось провести операцию")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Операция проведена",
+		Details: entry,
+	}
+	resp.Send(ctx, 201)
+}
+
+// @Summary		Изменить роль пользователя
+// @Tags			users
+// @Description	Назначение роли: admin, editor или player. Новая роль действует с момента обновления токенов пользователя
+// @ID				put-users-id-role
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			user_id			path		int					true	"user_id"
+// @Param			input			body		entity.RoleInput	true	"body"
+// @Success		200				{object}	Response
+// @Failure		400,401,403,404,409,422	{object}	Response
+// @Failure		500,503,504		{object}	Response
+// @Failure		default			{object}	Response
+// @Router			/users/{user_id}/role [put]
+func (h *Handler) UpdateUserRole(ctx *gin.Context) {
+	// Получение userID из параметров запроса
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID пользователя",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	var input entity.RoleInput
+	// Получение тела запроса
+	if err := ctx.BindJSON(&input); err != nil {
+		resp := Response{
+			Message: "Неверное тело запроса",
+		}
+		resp.SendError(ctx, err, 400)
+		return
+	}
+	// Валидация
+	if err := input.Validate(); err != nil {
+		resp := Response{
+			Message: err.Error(),
+		}
+		resp.Send(ctx, 422)
+		return
+	}
+	// Изменение роли
+	err = h.services.User.UpdateRole(ctx.Request.Context(), userID, input.Role)
+	if err != nil {
+		sendError(ctx, err, "Не удалось изменить роль пользователя")
+		return
+	}
+	// Отправка ответа
+	resp := Response{
+		Message: "Роль пользователя изменена",
+	}
+	resp.Send(ctx, 200)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"quest_service/internal/entity"
+	"quest_service/internal/logger"
+	"quest_service/internal/metrics"
+	"quest_service/internal/tracing"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	requestIDHeader     = "X-Request-ID"
+	identityCtx         = "identity"
+)
+
+// requestIDRe ограничивает принимаемые от клиента ID, чтобы в журнал не попадал произвольный текст
+var requestIDRe = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestID принимает X-Request-ID клиента или создаёт новый, возвращает его в ответе
+// и кладёт в контекст запроса, откуда его берут записи журнала всех слоёв
+func (h *Handler) requestID(ctx *gin.Context) {
+	requestID := ctx.GetHeader(requestIDHeader)
+	if !requestIDRe.MatchString(requestID) {
+		requestID = newRequestID()
+	}
+	ctx.Header(requestIDHeader, requestID)
+	trace.SpanFromContext(ctx.Request.Context()).SetAttributes(attribute.String("request_id", requestID))
+	ctx.Request = ctx.Request.WithContext(logger.WithRequestID(ctx.Request.Context(), requestID))
+	ctx.Next()
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// accessLog пишет одну запись на каждый обработанный запрос
+func (h *Handler) accessLog(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	attrs := []slog.Attr{
+		slog.String("method", ctx.Request.Method),
+		slog.String("route", ctx.FullPath()),
+		slog.String("path", ctx.Request.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int("bytes", ctx.Writer.Size()),
+		slog.String("client_ip", ctx.ClientIP()),
+	}
+	if identity, err := getIdentity(ctx); err == nil {
+		attrs = append(attrs, slog.Int("user_id", identity.UserID))
+	}
+
+	level := slog.LevelInfo
+	if status >= 500 {
+		level = slog.LevelError
+	}
+	slog.LogAttrs(ctx.Request.Context(), level, "HTTP-запрос", attrs...)
+}
+
+// instrument учитывает запрос в метриках по шаблону маршрута, а не по фактическому пути
+func (h *Handler) instrument(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+	metrics.ObserveHTTPRequest(ctx.Request.Method, ctx.FullPath(), ctx.Writer.Status(), time.Since(start))
+}
+
+// timeout ограничивает время обработки запроса. Контекст запроса передаётся в сервисы
+// и репозитории, поэтому запросы к БД прерываются по истечении срока или при отключении клиента.
+func (h *Handler) timeout(ctx *gin.Context) {
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), h.requestTimeout)
+	defer cancel()
+
+	ctx.Request = ctx.Request.WithContext(timeoutCtx)
+	ctx.Next()
+}
+
+// userIdentity проверяет access-токен и кладёт пользователя и его текущую роль в контекст запроса
+func (h *Handler) userIdentity(ctx *gin.Context) {
+	header := ctx.GetHeader(authorizationHeader)
+	headerParts := strings.Split(header, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" || headerParts[1] == "" {
+		resp := Response{
+			Message: "Отсутствует или неверный заголовок авторизации",
+		}
+		resp.Send(ctx, 401)
+		ctx.Abort()
+		return
+	}
+
+	identity, err := h.services.Authorization.ParseToken(ctx.Request.Context(), headerParts[1])
+	if err != nil {
+		sendError(ctx, err, "Не удалось проверить токен")
+		ctx.Abort()
+		return
+	}
+
+	ctx.Set(identityCtx, identity)
+	trace.SpanFromContext(ctx.Request.Context()).SetAttributes(tracing.UserID(identity.UserID))
+}
+
+// authorize пропускает запрос, только если роль пользователя имеет право на маршрут.
+// Маршруты, отсутствующие в таблице прав, запрещены.
+func (h *Handler) authorize(ctx *gin.Context) {
+	identity, err := getIdentity(ctx)
+	if err != nil {
+		sendError(ctx, err, "Не удалось определить пользователя")
+		ctx.Abort()
+		return
+	}
+
+	permission, ok := routePermissions[ctx.Request.Method+" "+ctx.FullPath()]
+	if !ok || !identity.Can(permission) {
+		resp := Response{
+			Message: "Недостаточно прав",
+		}
+		resp.Send(ctx, 403)
+		ctx.Abort()
+		return
+	}
+}
+
+// getIdentity возвращает аутентифицированного пользователя
+func getIdentity(ctx *gin.Context) (*entity.Identity, error) {
+	value, ok := ctx.Get(identityCtx)
+	if !ok {
+		return nil, errors.New("identity not found in context")
+	}
+	identity, ok := value.(*entity.Identity)
+	if !ok {
+		return nil, errors.New("identity is of invalid type")
+	}
+	return identity, nil
+}
+
+// can сообщает, есть ли у аутентифицированного пользователя право permission
+func can(ctx *gin.Context, permission entity.Permission) bool {
+	identity, err := getIdentity(ctx)
+	return err == nil && identity.Can(permission)
+}
+
+// questVisibility возвращает, какие скрытые квесты видит пользователь: вне окна доступности —
+// администратор, неопубликованные — редакторы квестов
+func questVisibility(ctx *gin.Context) entity.QuestVisibility {
+	return entity.QuestVisibility{
+		Closed:      can(ctx, entity.PermQuestsReadHidden),
+		Unpublished: can(ctx, entity.PermQuestsManage),
+	}
+}
+
+// getUserID возвращает ID аутентифицированного пользователя
+func getUserID(ctx *gin.Context) (int, error) {
+	identity, err := getIdentity(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return identity.UserID, nil
+}
+
+// getTargetUserID читает ID пользователя из пути. Доступ к чужим данным есть только
+// у ролей с правом управления пользователями. При ошибке ответ уже отправлен.
+func getTargetUserID(ctx *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		resp := Response{
+			Message: "Неверный ID пользователя",
+		}
+		resp.SendError(ctx, err, 400)
+		return 0, false
+	}
+	identity, err := getIdentity(ctx)
+	if err != nil {
+		sendError(ctx, err, "Не удалось определить пользователя")
+		return 0, false
+	}
+	if userID != identity.UserID && !identity.Can(entity.PermUsersManage) {
+		resp := Response{
+			Message: "Нет доступа к данным другого пользователя",
+		}
+		resp.Send(ctx, 403)
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"quest_service/internal/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutSetsDeadline(t *testing.T) {
+	h := &Handler{requestTimeout: time.Minute}
+	router := gin.New()
+	var deadline time.Time
+	var ok bool
+	router.GET("/", h.timeout, func(ctx *gin.Context) {
+		deadline, ok = ctx.Request.Context().Deadline()
+		ctx.Status(http.StatusOK)
+	})
+
+	start := time.Now()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !ok || deadline.Before(start.Add(time.Minute)) || deadline.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("deadline = %v (set %v), want about a minute from %v", deadline, ok, start)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	h := &Handler{}
+	router := gin.New()
+	var fromCtx string
+	router.GET("/", h.requestID, func(ctx *gin.Context) {
+		fromCtx = logger.RequestID(ctx.Request.Context())
+		ctx.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"ID клиента", "abc-123:x.y_z", true},
+		{"без заголовка", "", false},
+		{"недопустимые символы", "abc 123\n", false},
+		{"слишком длинный", strings.Repeat("a", 129), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(requestIDHeader, tt.header)
+			}
+			router.ServeHTTP(recorder, req)
+
+			got := recorder.Header().Get(requestIDHeader)
+			if got != fromCtx {
+				t.Fatalf("response ID %q != context ID %q", got, fromCtx)
+			}
+			if tt.keep && got != tt.header {
+				t.Fatalf("request ID = %q, want client ID %q", got, tt.header)
+			}
+			if !tt.keep && (got == tt.header || len(got) != 32) {
+				t.Fatalf("request ID = %q, want a generated 32-char ID", got)
+			}
+		})
+	}
+}
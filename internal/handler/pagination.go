@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"quest_service/internal/entity"
+	"strconv"
+)
+
+// getPage читает параметры limit и offset из строки запроса
+func getPage(ctx *gin.Context) (entity.Page, error) {
+	page := entity.Page{Limit: entity.DefaultPageLimit}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			return page, fmt.Errorf("Неверный параметр limit")
+		}
+		page.Limit = value
+	}
+	if offset := ctx.Query("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil {
+			return page, fmt.Errorf("Неверный параметр offset")
+		}
+		page.Offset = value
+	}
+
+	return page, page.Validate()
+}
+
+// getQuestFilter читает параметры фильтрации, сортировки и пагинации списка квестов
+func getQuestFilter(ctx *gin.Context) (*entity.QuestFilter, error) {
+	filter := &entity.QuestFilter{
+		Name:   ctx.Query("name"),
+		Status: ctx.Query("status"),
+		Sort:   ctx.DefaultQuery("sort", entity.QuestSortCreatedAt),
+		Limit:  entity.DefaultPageLimit,
+		Cursor: ctx.Query("cursor"),
+	}
+
+	switch ctx.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return nil, fmt.Errorf("Параметр order должен быть asc или desc")
+	}
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("Неверный параметр limit")
+		}
+		filter.Limit = value
+	}
+	if minCost := ctx.Query("min_cost"); minCost != "" {
+		value, err := strconv.Atoi(minCost)
+		if err != nil {
+			return nil, fmt.Errorf("Неверный параметр min_cost")
+		}
+		filter.MinCost = &value
+	}
+	if maxCost := ctx.Query("max_cost"); maxCost != "" {
+		value, err := strconv.Atoi(maxCost)
+		if err != nil {
+			return nil, fmt.Errorf("Неверный параметр max_cost")
+		}
+		filter.MaxCost = &value
+	}
+	if hasReusable := ctx.Query("has_reusable_tasks"); hasReusable != "" {
+		value, err := strconv.ParseBool(hasReusable)
+		if err != nil {
+			return nil, fmt.Errorf("Неверный параметр has_reusable_tasks")
+		}
+		filter.HasReusableTasks = &value
+	}
+
+	return filter, filter.Validate()
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"quest_service/internal/entity"
+	"testing"
+)
+
+// queryContext возвращает контекст запроса с указанной строкой параметров
+func queryContext(query string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	return ctx
+}
+
+func TestGetPage(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    entity.Page
+		wantErr bool
+	}{
+		{"", entity.Page{Limit: entity.DefaultPageLimit}, false},
+		{"limit=5&offset=10", entity.Page{Limit: 5, Offset: 10}, false},
+		{"limit=abc", entity.Page{}, true},
+		{"offset=abc", entity.Page{}, true},
+		{"limit=0", entity.Page{}, true},
+		{"offset=-1", entity.Page{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			page, err := getPage(queryContext(tt.query))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getPage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && page != tt.want {
+				t.Fatalf("getPage() = %+v, want %+v", page, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetQuestFilter(t *testing.T) {
+	filter, err := getQuestFilter(queryContext("sort=cost&order=desc&limit=5&min_cost=10&max_cost=20&has_reusable_tasks=true&name=a&cursor=c"))
+	if err != nil {
+		t.Fatalf("getQuestFilter: %v", err)
+	}
+	if filter.Sort != entity.QuestSortCost || !filter.Desc || filter.Limit != 5 || filter.Name != "a" || filter.Cursor != "c" ||
+		*filter.MinCost != 10 || *filter.MaxCost != 20 || !*filter.HasReusableTasks {
+		t.Fatalf("getQuestFilter() = %+v", filter)
+	}
+
+	defaults, err := getQuestFilter(queryContext(""))
+	if err != nil {
+		t.Fatalf("getQuestFilter: %v", err)
+	}
+	if defaults.Sort != entity.QuestSortCreatedAt || defaults.Desc || defaults.Limit != entity.DefaultPageLimit {
+		t.Fatalf("getQuestFilter() defaults = %+v", defaults)
+	}
+
+	for _, query := range []string{
+		"order=up",
+		"sort=id",
+		"limit=1000",
+		"min_cost=x",
+		"min_cost=-1",
+		"min_cost=20&max_cost=10",
+		"has_reusable_tasks=maybe",
+	} {
+		if _, err := getQuestFilter(queryContext(query)); err == nil {
+			t.Errorf("getQuestFilter(%q) error = nil, want error", query)
+		}
+	}
+}
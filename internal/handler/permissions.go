@@ -0,0 +1,43 @@
+package handler
+
+import "quest_service/internal/entity"
+
+// routePermissions — права, необходимые для защищённых маршрутов. Ключ: "МЕТОД шаблон пути"
+var routePermissions = map[string]entity.Permission{
+	// Пользователи
+	"POST /api/users/":                         entity.PermUsersManage,
+	"GET /api/users/:id":                       entity.PermAccountRead,
+	"PUT /api/users/:id/role":                  entity.PermUsersManage,
+	"GET /api/users/:id/quests":                entity.PermAccountRead,
+	"GET /api/users/:id/quests/:questId":       entity.PermAccountRead,
+	"GET /api/users/:id/quests/:questId/graph": entity.PermAccountRead,
+	"GET /api/users/:id/balance/":              entity.PermAccountRead,
+	"GET /api/users/:id/transactions/":         entity.PermAccountRead,
+	"POST /api/users/:id/transactions/":        entity.PermPurchase,
+	// Квесты
+	"GET /api/quests/":                  entity.PermQuestsRead,
+	"GET /api/quests/:id":               entity.PermQuestsRead,
+	"POST /api/quests/":                 entity.PermQuestsManage,
+	"PATCH /api/quests/:id":             entity.PermQuestsManage,
+	"DELETE /api/quests/:id":            entity.PermQuestsManage,
+	"POST /api/quests/:id/publish":      entity.PermQuestsManage,
+	"POST /api/quests/:id/pause":        entity.PermQuestsManage,
+	"POST /api/quests/:id/archive":      entity.PermQuestsManage,
+	"GET /api/quests/:id/prerequisites": entity.PermQuestsRead,
+	"PUT /api/quests/:id/prerequisites": entity.PermQuestsManage,
+	"PUT /api/quests/:id/tasks/order":   entity.PermQuestsManage,
+	"POST /api/quests/test":             entity.PermSeedData,
+	// Задания
+	"POST /api/tasks/":         entity.PermQuestsManage,
+	"GET /api/tasks/:id":       entity.PermQuestsRead,
+	"PATCH /api/tasks/:id":     entity.PermQuestsManage,
+	"DELETE /api/tasks/:id":    entity.PermQuestsManage,
+	"POST /api/task-progress/": entity.PermTasksComplete,
+	// Корзина
+	"GET /api/admin/trash/quests":              entity.PermTrashManage,
+	"GET /api/admin/trash/tasks":               entity.PermTrashManage,
+	"POST /api/admin/trash/quests/:id/restore": entity.PermTrashManage,
+	"POST /api/admin/trash/tasks/:id/restore":  entity.PermTrashManage,
+	"DELETE /api/admin/trash/quests/:id":       entity.PermTrashManage,
+	"DELETE /api/admin/trash/tasks/:id":        entity.PermTrashManage,
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"quest_service/configs"
+	"quest_service/internal/entity"
+	"strings"
+	"testing"
+)
+
+// publicRoutePrefixes — маршруты API без проверки прав
+var publicRoutePrefixes = []string{"/api/auth/", "/api/swagger/"}
+
+// isPublicRoute сообщает, что маршрут не требует прав: служебные маршруты вне /api и публичные маршруты API
+func isPublicRoute(path string) bool {
+	if !strings.HasPrefix(path, "/api/") {
+		return true
+	}
+	for _, prefix := range publicRoutePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Каждый защищённый маршрут описан в таблице прав, а таблица не содержит лишних записей
+func TestRoutePermissionsCoverRoutes(t *testing.T) {
+	// Включаем все возможности, чтобы зарегистрировать все маршруты
+	h := &Handler{features: configs.FeaturesConfig{TestData: true, Metrics: true}}
+	registered := map[string]bool{}
+	for _, route := range h.InitRoutes().Routes() {
+		if isPublicRoute(route.Path) {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := routePermissions[key]; !ok {
+			t.Errorf("route %q has no entry in routePermissions", key)
+		}
+	}
+	for key := range routePermissions {
+		if !registered[key] {
+			t.Errorf("routePermissions entry %q has no route", key)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	h := &Handler{}
+	router := gin.New()
+	withRole := func(role string) gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.Set(identityCtx, &entity.Identity{UserID: 1, Role: role})
+		}
+	}
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	router.POST("/api/quests/", withRole(entity.RolePlayer), h.authorize, ok)
+	router.GET("/api/quests/", withRole(entity.RolePlayer), h.authorize, ok)
+	router.GET("/api/unlisted", withRole(entity.RoleAdmin), h.authorize, ok)
+	router.GET("/api/anonymous", h.authorize, ok)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/api/quests/", http.StatusOK},
+		{http.MethodPost, "/api/quests/", http.StatusForbidden},
+		// Маршрут вне таблицы прав запрещён даже администратору
+		{http.MethodGet, "/api/unlisted", http.StatusForbidden},
+		{http.MethodGet, "/api/anonymous", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
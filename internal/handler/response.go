@@ -1,20 +1,59 @@
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"github.com/gin-gonic/gin"
-	"log"
+	"log/slog"
+	"net/http"
 )
 
 type Response struct {
 	Message string `json:"message,omitempty"`
+	Code    string `json:"code,omitempty"`
 	Details any    `json:"details,omitempty"`
 }
 
 func (r *Response) Send(ctx *gin.Context, code int) {
+	r.setDefaultCode(code)
 	ctx.JSON(code, r)
 }
 
+// SendCached отправляет ответ с ETag по хешу тела. Если тело не изменилось с прошлого
+// запроса клиента, отвечает 304 без тела
+func (r *Response) SendCached(ctx *gin.Context, code int) {
+	r.setDefaultCode(code)
+	body, err := json.Marshal(r)
+	if err != nil {
+		resp := Response{
+			Message: "Не удалось сформировать ответ",
+		}
+		resp.SendError(ctx, err, http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(body)
+	if notModified(ctx, `"`+hex.EncodeToString(sum[:16])+`"`) {
+		return
+	}
+	ctx.Data(code, "application/json; charset=utf-8", body)
+}
+
+// SendError отправляет ответ с ошибкой. Ошибки сервера пишутся в журнал с уровнем error,
+// ошибки клиента — с уровнем debug
 func (r *Response) SendError(ctx *gin.Context, err error, code int) {
-	log.Println(err)
+	level := slog.LevelDebug
+	if code >= 500 {
+		level = slog.LevelError
+	}
+	slog.Log(ctx.Request.Context(), level, r.Message, "status", code, "error", err)
+	r.setDefaultCode(code)
 	ctx.JSON(code, r)
 }
+
+// setDefaultCode проставляет машиночитаемый код ошибки по HTTP-статусу, если он не задан явно
+func (r *Response) setDefaultCode(status int) {
+	if r.Code == "" && status >= 400 {
+		r.Code = statusCodes[status]
+	}
+}
@@ -1,76 +1,142 @@
 package handler
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"log"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"quest_service/configs"
 	_ "quest_service/docs"
+	"quest_service/internal/metrics"
 	"quest_service/internal/service"
+	"quest_service/internal/tracing"
+	"time"
 )
 
 type Handler struct {
-	services *service.Service
+	services       *service.Service
+	requestTimeout time.Duration
+	features       configs.FeaturesConfig
 }
 
-func NewHandler(services *service.Service) *Handler {
-	return &Handler{services: services}
+func NewHandler(services *service.Service, cfg configs.Config) *Handler {
+	return &Handler{services: services, requestTimeout: cfg.HTTP.RequestTimeout, features: cfg.Features}
 }
 
-func (h *Handler) InitRoutes(port string) {
+func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
 	gin.SetMode(gin.ReleaseMode)
+	router.Use(otelgin.Middleware(tracing.ServiceName), h.requestID, h.accessLog, h.instrument)
 	//
-	api := router.Group("/api")
+	api := router.Group("/api", h.timeout)
 	{
-		users := api.Group("/users")
+		auth := api.Group("/auth")
+		{
+			// Регистрация
+			auth.POST("/sign-up", h.SignUp)
+			// Вход
+			auth.POST("/sign-in", h.SignIn)
+			// Обновление токенов
+			auth.POST("/refresh", h.Refresh)
+		}
+
+		users := api.Group("/users", h.userIdentity, h.authorize)
 		{
 			// Создание пользователя
 			users.POST("/", h.CreateUser)
+			// Получение профиля
+			users.GET("/:id", h.GetUser)
+			// Изменение роли
+			users.PUT(":id/role", h.UpdateUserRole)
+			// Прогресс по квестам
+			users.GET("/:id/quests", h.GetUserQuests)
+			// Прогресс по квесту с разбивкой по заданиям
+			users.GET("/:id/quests/:questId", h.GetUserQuest)
+			// Граф заданий квеста с доступностью для пользователя
+			users.GET("/:id/quests/:questId/graph", h.GetUserQuestGraph)
 
 			balance := users.Group(":id/balance")
 			{
 				// Получение баланса
 				balance.GET("/", h.GetBalance)
 			}
+
+			transactions := users.Group(":id/transactions")
+			{
+				// История операций по балансу
+				transactions.GET("/", h.GetTransactions)
+				// Ручная проводка
+				transactions.POST("/", h.CreateTransaction)
+			}
 		}
 
-		quests := api.Group("/quests")
+		quests := api.Group("/quests", h.userIdentity, h.authorize)
 		{
 			// Создание квеста
 			quests.POST("/", h.CreateQuest)
 			//	Получение квестов
 			quests.GET("/", h.GetQuests)
+			//	Получение квеста
+			quests.GET("/:id", h.GetQuest)
 			//	Обновление квеста
-			quests.PUT("/:id", h.UpdateQuest)
+			quests.PATCH("/:id", h.UpdateQuest)
 			//	Удаление квеста
 			quests.DELETE("/:id", h.DeleteQuest)
+			//	Смена статуса квеста
+			quests.POST("/:id/publish", h.PublishQuest)
+			quests.POST("/:id/pause", h.PauseQuest)
+			quests.POST("/:id/archive", h.ArchiveQuest)
+			//	Квесты-условия
+			quests.GET("/:id/prerequisites", h.GetPrerequisites)
+			quests.PUT("/:id/prerequisites", h.SetPrerequisites)
+			//	Порядок заданий квеста
+			quests.PUT("/:id/tasks/order", h.ReorderTasks)
 		}
 
-		tasks := api.Group("/tasks")
+		tasks := api.Group("/tasks", h.userIdentity, h.authorize)
 		{
 			// Создание задания
 			tasks.POST("/", h.CreateTask)
+			//	Получение задания
+			tasks.GET("/:id", h.GetTask)
 			//	Обновление задания
-			tasks.PUT("/:id", h.UpdateTask)
+			tasks.PATCH("/:id", h.UpdateTask)
 			//	Удаление задания
 			tasks.DELETE("/:id", h.DeleteTask)
 		}
 
-		taskProgress := api.Group("/task-progress")
+		taskProgress := api.Group("/task-progress", h.userIdentity, h.authorize)
 		{
 			// Завершение квеста
 			taskProgress.POST("/", h.TaskCompletion)
 		}
 
-		//	Добавление тестовых данных
-		quests.POST("/test", h.CreateTestQuestData)
+		trash := api.Group("/admin/trash", h.userIdentity, h.authorize)
+		{
+			// Удалённые квесты и задания
+			trash.GET("/quests", h.GetDeletedQuests)
+			trash.GET("/tasks", h.GetDeletedTasks)
+			// Восстановление
+			trash.POST("/quests/:id/restore", h.RestoreQuest)
+			trash.POST("/tasks/:id/restore", h.RestoreTask)
+			// Окончательное удаление
+			trash.DELETE("/quests/:id", h.PurgeQuest)
+			trash.DELETE("/tasks/:id", h.PurgeTask)
+		}
+
+		if h.features.TestData {
+			//	Добавление тестовых данных
+			quests.POST("/test", h.CreateTestQuestData)
+		}
 	}
 	router.GET("api/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	fmt.Println("Swagger docs: http://127.0.0.1:" + port + "/api/swagger/")
-	err := router.Run(":" + port)
-	if err != nil {
-		log.Fatal(err)
+
+	// Служебные маршруты для оркестратора, без аутентификации
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.timeout, h.Readiness)
+	router.GET("/version", h.Version)
+	if h.features.Metrics {
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
 	}
+	return router
 }
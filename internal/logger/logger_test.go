@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"testing"
+)
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "verbose", FormatJSON); err == nil {
+		t.Error("New(level=verbose) error = nil, want error")
+	}
+	if _, err := New(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("New(format=xml) error = nil, want error")
+	}
+}
+
+func TestRequestIDAttribute(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(&buf, "info", FormatJSON)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// Атрибут добавляется и в логгерах, созданных через With
+	log = log.With("component", "test")
+
+	log.InfoContext(WithRequestID(context.Background(), "req-1"), "с контекстом")
+	log.InfoContext(context.Background(), "без контекста")
+	log.Debug("ниже уровня")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("records = %d, want 2: %s", len(lines), buf.String())
+	}
+	var first, second map[string]any
+	if err = json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first["request_id"] != "req-1" || first["component"] != "test" {
+		t.Errorf("first record = %v, want request_id and component", first)
+	}
+	if _, ok := second["request_id"]; ok {
+		t.Errorf("second record = %v, want no request_id", second)
+	}
+}
+
+func TestTraceIDAttribute(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(&buf, "info", FormatJSON)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{4, 5, 6},
+	})
+	log.InfoContext(trace.ContextWithSpanContext(context.Background(), spanCtx), "в трассе")
+
+	var record map[string]any
+	if err = json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["trace_id"] != spanCtx.TraceID().String() {
+		t.Fatalf("record = %v, want trace_id %s", record, spanCtx.TraceID())
+	}
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"quest_service/internal/entity"
+	"strconv"
+	"time"
+)
+
+// Метки ограничены конечными наборами значений: шаблон маршрута вместо пути,
+// причина проводки вместо ID квеста. Разбивка по квестам и пользователям есть в журнале проводок.
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route template, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route template and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	taskCompletionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "quest_task_completion_duration_seconds",
+		Help:    "Latency of the task completion transaction by outcome.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"result"})
+
+	tasksCompleted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "quest_tasks_completed_total",
+		Help: "Task completions recorded.",
+	})
+
+	questsCompleted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "quest_quests_completed_total",
+		Help: "Quests completed for the first time by a user.",
+	})
+
+	payout = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quest_payout_total",
+		Help: "Currency paid out to users by ledger reason.",
+	}, []string{"reason"})
+
+	questPayout = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "quest_completion_payout",
+		Help:    "Currency paid out per completed quest: the quest bonus.",
+		Buckets: []float64{0, 10, 50, 100, 250, 500, 1000, 2500, 5000},
+	})
+)
+
+// Исходы выполнения задания для метки result
+const (
+	ResultOK               = "ok"
+	ResultAlreadyCompleted = "already_completed"
+	ResultNotFound         = "not_found"
+	ResultDeleted          = "deleted"
+	ResultOutsideWindow    = "outside_window"
+	ResultQuestInactive    = "quest_inactive"
+	ResultQuestLocked      = "quest_locked"
+	ResultOutOfOrder       = "out_of_order"
+	ResultError            = "error"
+)
+
+// unmatchedRoute — метка для запросов к несуществующим маршрутам, чтобы произвольные пути
+// не порождали новые ряды
+const unmatchedRoute = "unmatched"
+
+// otherMethod — метка для нестандартных HTTP-методов: метод приходит от клиента как есть
+const otherMethod = "other"
+
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDBStats публикует состояние пула соединений: открытые, занятые и простаивающие
+// соединения, число и суммарное время ожидания свободного соединения
+func RegisterDBStats(db *sql.DB, dbName string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	if !knownMethods[method] {
+		method = otherMethod
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func ObserveTaskCompletion(result string, duration time.Duration) {
+	taskCompletionDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// RecordTaskCompleted учитывает выполненное задание, завершённый квест и выплаченные награды
+func RecordTaskCompleted(result *entity.TaskCompletionResult) {
+	tasksCompleted.Inc()
+	payout.WithLabelValues(entity.LedgerReasonTask).Add(float64(result.Reward))
+	if result.QuestCompleted {
+		questsCompleted.Inc()
+		payout.WithLabelValues(entity.LedgerReasonQuestBonus).Add(float64(result.QuestReward))
+		questPayout.Observe(float64(result.QuestReward))
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"quest_service/internal/entity"
+	"testing"
+	"time"
+)
+
+func TestObserveHTTPRequestUnmatchedRoute(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404"))
+	ObserveHTTPRequest("GET", "", 404, time.Millisecond)
+	ObserveHTTPRequest("GET", "", 404, time.Millisecond)
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404")); got != before+2 {
+		t.Fatalf("unmatched requests = %v, want %v", got, before+2)
+	}
+}
+
+// Нестандартные методы не порождают новых рядов метрики
+func TestObserveHTTPRequestOtherMethod(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues(otherMethod, unmatchedRoute, "404"))
+	ObserveHTTPRequest("FOO", "", 404, time.Millisecond)
+	ObserveHTTPRequest("BAR", "", 404, time.Millisecond)
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues(otherMethod, unmatchedRoute, "404")); got != before+2 {
+		t.Fatalf("other method requests = %v, want %v", got, before+2)
+	}
+}
+
+func TestRecordTaskCompleted(t *testing.T) {
+	tasksBefore := testutil.ToFloat64(tasksCompleted)
+	questsBefore := testutil.ToFloat64(questsCompleted)
+	taskPayoutBefore := testutil.ToFloat64(payout.WithLabelValues(entity.LedgerReasonTask))
+	bonusBefore := testutil.ToFloat64(payout.WithLabelValues(entity.LedgerReasonQuestBonus))
+
+	RecordTaskCompleted(&entity.TaskCompletionResult{Reward: 10})
+	RecordTaskCompleted(&entity.TaskCompletionResult{Reward: 5, QuestCompleted: true, QuestReward: 100})
+
+	if got := testutil.ToFloat64(tasksCompleted) - tasksBefore; got != 2 {
+		t.Errorf("tasks completed = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(questsCompleted) - questsBefore; got != 1 {
+		t.Errorf("quests completed = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(payout.WithLabelValues(entity.LedgerReasonTask)) - taskPayoutBefore; got != 15 {
+		t.Errorf("task payout = %v, want 15", got)
+	}
+	if got := testutil.ToFloat64(payout.WithLabelValues(entity.LedgerReasonQuestBonus)) - bonusBefore; got != 100 {
+		t.Errorf("quest bonus payout = %v, want 100", got)
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+// Ошибки репозитория, не зависящие от драйвера БД
+var (
+	ErrNotFound            = errors.New("запись не найдена")
+	ErrUniqueViolation     = errors.New("нарушено ограничение уникальности")
+	ErrForeignKeyViolation = errors.New("нарушена ссылочная целостность")
+	ErrCheckViolation      = errors.New("нарушено ограничение CHECK")
+	ErrInvalidCursor       = errors.New("неверный курсор")
+	ErrVersionMismatch     = errors.New("версия записи не совпадает")
+	ErrParentDeleted       = errors.New("родительская запись удалена")
+	ErrInvalidTransition   = errors.New("недопустимый переход статуса")
+	ErrReferenceMissing    = errors.New("связанная запись не найдена")
+	ErrCycle               = errors.New("связи образуют цикл")
+	ErrSetMismatch         = errors.New("набор записей не совпадает")
+	ErrTxConflict          = errors.New("транзакция не прошла из-за конфликтов с параллельными запросами")
+)
+
+// Коды SQLSTATE, которые различает репозиторий
+const (
+	codeUniqueViolation     = "23505"
+	codeForeignKeyViolation = "23503"
+	codeCheckViolation      = "23514"
+	codeQueryCanceled       = "57014"
+)
+
+// ConstraintError — нарушение ограничения с именем сработавшего ограничения
+type ConstraintError struct {
+	Kind       error
+	Constraint string
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// translateError приводит ошибки драйвера к ошибкам репозитория по SQLSTATE.
+// ctx — контекст запроса к БД: по нему отменённый сервером запрос сопоставляется с причиной отмены
+func translateError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case codeQueryCanceled:
+		// lib/pq отменяет запрос на сервере по истечении контекста, и ошибка приходит от Postgres.
+		// Оборачиваем её причиной отмены, чтобы обработчик отличил таймаут БД от внутренней ошибки
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("%w: %w", ctxErr, err)
+		}
+	case codeUniqueViolation:
+		return &ConstraintError{Kind: ErrUniqueViolation, Constraint: pqErr.Constraint, Err: err}
+	case codeForeignKeyViolation:
+		return &ConstraintError{Kind: ErrForeignKeyViolation, Constraint: pqErr.Constraint, Err: err}
+	case codeCheckViolation:
+		return &ConstraintError{Kind: ErrCheckViolation, Constraint: pqErr.Constraint, Err: err}
+	}
+	return err
+}
+
+// IsConstraint сообщает, нарушено ли ограничение с указанным именем
+func IsConstraint(err error, constraint string) bool {
+	var constraintErr *ConstraintError
+	return errors.As(err, &constraintErr) && constraintErr.Constraint == constraint
+}
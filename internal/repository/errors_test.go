@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"testing"
+)
+
+func TestTranslateError(t *testing.T) {
+	other := errors.New("boom")
+	tests := []struct {
+		name       string
+		err        error
+		kind       error
+		constraint string
+	}{
+		{"нет записи", sql.ErrNoRows, ErrNotFound, ""},
+		{"обёрнутое отсутствие записи", fmt.Errorf("get: %w", sql.ErrNoRows), ErrNotFound, ""},
+		{"уникальность", &pq.Error{Code: codeUniqueViolation, Constraint: "users_username_key"}, ErrUniqueViolation, "users_username_key"},
+		{"внешний ключ", &pq.Error{Code: codeForeignKeyViolation, Constraint: "tasks_quest_id_fkey"}, ErrForeignKeyViolation, "tasks_quest_id_fkey"},
+		{"check", &pq.Error{Code: codeCheckViolation, Constraint: "users_balance_check"}, ErrCheckViolation, "users_balance_check"},
+		{"прочая ошибка драйвера", &pq.Error{Code: "42601"}, nil, ""},
+		{"отмена запроса без отмены контекста", &pq.Error{Code: codeQueryCanceled}, nil, ""},
+		{"не ошибка драйвера", other, other, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(context.Background(), tt.err)
+			if tt.kind == nil {
+				if got != tt.err {
+					t.Fatalf("translateError() = %v, want original error", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.kind) {
+				t.Fatalf("translateError() = %v, want %v", got, tt.kind)
+			}
+			if tt.constraint != "" && !IsConstraint(got, tt.constraint) {
+				t.Fatalf("IsConstraint(%q) = false", tt.constraint)
+			}
+		})
+	}
+	if translateError(context.Background(), nil) != nil {
+		t.Fatal("translateError(context.Background(), nil) != nil")
+	}
+}
+
+func TestIsConstraintWrapped(t *testing.T) {
+	err := fmt.Errorf("tx: %w", translateError(context.Background(), &pq.Error{Code: codeUniqueViolation, Constraint: "a"}))
+	if !IsConstraint(err, "a") || IsConstraint(err, "b") {
+		t.Fatalf("IsConstraint() mismatch for %v", err)
+	}
+}
+
+// Запрос, отменённый сервером по истечении контекста, сопоставляется с причиной отмены
+func TestTranslateErrorQueryCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	pqErr := &pq.Error{Code: codeQueryCanceled}
+
+	got := translateError(ctx, pqErr)
+	if !errors.Is(got, context.DeadlineExceeded) {
+		t.Fatalf("translateError() = %v, want context.DeadlineExceeded", got)
+	}
+	var driverErr *pq.Error
+	if !errors.As(got, &driverErr) {
+		t.Fatalf("translateError() = %v, want wrapped *pq.Error", got)
+	}
+}
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Таблица версий совместима с golang-migrate: одна строка с текущей версией
+const createVersionTableQuery = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)
+`
+
+// migrationLockID — ключ advisory-блокировки, под которой выполняются миграции.
+// Несколько экземпляров сервиса, стартующих одновременно, применяют миграции по очереди.
+const migrationLockID = 7423001
+
+const codeUndefinedTable = "42P01"
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version int
+	Dirty   bool
+	Latest  int
+	Pending []Migration
+}
+
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+// NewMigrator читает пары up/down-файлов из fsys
+func NewMigrator(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d: both up and down files are required", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	return m.status(ctx, m.db)
+}
+
+// Up применяет все неприменённые миграции.
+// База новее этой сборки не трогается: так старые экземпляры переживают выкатку новой версии.
+func (m *Migrator) Up() error {
+	return m.withLock(func(conn *sqlx.Conn) error {
+		status, err := m.status(context.Background(), conn)
+		if err != nil {
+			return err
+		}
+		if status.Version > m.latest() {
+			slog.Warn("Версия схемы новее известной сборке, миграции пропущены", "version", status.Version, "latest", m.latest())
+			return nil
+		}
+		return m.migrate(conn, status, m.latest())
+	})
+}
+
+// Down откатывает одну последнюю миграцию
+func (m *Migrator) Down() error {
+	return m.withLock(func(conn *sqlx.Conn) error {
+		status, err := m.status(context.Background(), conn)
+		if err != nil {
+			return err
+		}
+		if status.Version == 0 {
+			return errors.New("no migrations to roll back")
+		}
+		return m.migrate(conn, status, m.previous(status.Version))
+	})
+}
+
+// Goto приводит схему к указанной версии, применяя или откатывая миграции по одной
+func (m *Migrator) Goto(version int) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.withLock(func(conn *sqlx.Conn) error {
+		status, err := m.status(context.Background(), conn)
+		if err != nil {
+			return err
+		}
+		return m.migrate(conn, status, version)
+	})
+}
+
+// Force записывает версию без выполнения миграций и снимает признак dirty.
+// Нужен для баз, где схема была применена вручную.
+func (m *Migrator) Force(version int) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.withLock(func(conn *sqlx.Conn) error {
+		tx, err := conn.BeginTxx(context.Background(), nil)
+		if err != nil {
+			return err
+		}
+		if err = setVersion(tx, version); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+func (m *Migrator) migrate(conn *sqlx.Conn, status *MigrationStatus, target int) error {
+	if status.Dirty {
+		return fmt.Errorf("database is dirty at version %d, fix it manually and run force", status.Version)
+	}
+	if status.Version != 0 && m.index(status.Version) < 0 {
+		return fmt.Errorf("database version %d is unknown to this build", status.Version)
+	}
+
+	current := status.Version
+	for current != target {
+		var query string
+		var next int
+		if current < target {
+			next = m.next(current)
+			query = m.migrations[m.index(next)].Up
+			slog.Info("Применение миграции", "version", next)
+		} else {
+			next = m.previous(current)
+			query = m.migrations[m.index(current)].Down
+			slog.Info("Откат миграции", "version", current)
+		}
+
+		// DDL в PostgreSQL транзакционен: миграция и новая версия фиксируются вместе
+		tx, err := conn.BeginTxx(context.Background(), nil)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(query); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", max(current, next), err)
+		}
+		if err = setVersion(tx, next); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		current = next
+	}
+	return nil
+}
+
+func (m *Migrator) status(ctx context.Context, q sqlx.QueryerContext) (*MigrationStatus, error) {
+	status := &MigrationStatus{Latest: m.latest()}
+
+	row := q.QueryRowxContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	err := row.Scan(&status.Version, &status.Dirty)
+	// Таблицы версий ещё нет — миграции не применялись
+	if err != nil && !errors.Is(err, sql.ErrNoRows) && !isUndefinedTable(err) {
+		return nil, err
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > status.Version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// withLock выполняет fn на отдельном соединении под advisory-блокировкой
+func (m *Migrator) withLock(fn func(conn *sqlx.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err = conn.ExecContext(ctx, createVersionTableQuery); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func isUndefinedTable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == codeUndefinedTable
+}
+
+func setVersion(tx *sqlx.Tx, version int) error {
+	if _, err := tx.Exec(`DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	_, err := tx.Exec(`INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version)
+	return err
+}
+
+func (m *Migrator) latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) index(version int) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Migrator) next(version int) int {
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			return migration.Version
+		}
+	}
+	return version
+}
+
+func (m *Migrator) previous(version int) int {
+	prev := 0
+	for _, migration := range m.migrations {
+		if migration.Version >= version {
+			break
+		}
+		prev = migration.Version
+	}
+	return prev
+}
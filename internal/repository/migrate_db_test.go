@@ -0,0 +1,75 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"quest_service/internal/entity"
+	"quest_service/schema"
+	"testing"
+)
+
+// Все down-миграции откатывают схему до пустой, после чего up-миграции применяются заново
+func TestMigrationsRoundTrip(t *testing.T) {
+	db := OpenTestDB(t)
+	m, err := NewMigrator(db, schema.Migrations)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if err = m.Goto(0); err != nil {
+		t.Fatalf("Goto(0): %v", err)
+	}
+	var tables int
+	err = db.Get(&tables, `SELECT count(*) FROM pg_tables WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatalf("tables after Goto(0) = %d, want 0", tables)
+	}
+
+	if err = m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	status, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Version != m.latest() || status.Dirty || len(status.Pending) != 0 {
+		t.Fatalf("status = %+v, want clean version %d", status, m.latest())
+	}
+}
+
+// Откат каскадного удаления возвращает задания удалённых квестов, но не задания, удалённые отдельно
+func TestCascadeDeletionDown(t *testing.T) {
+	db := OpenTestDB(t)
+	ctx := context.Background()
+	questID, taskIDs := CreateTestQuest(t, db, 10, TestTask{Cost: 1}, TestTask{Cost: 1})
+	if _, err := db.Exec(`UPDATE tasks SET deleted_at = NOW() - INTERVAL '1 hour' WHERE id = $1`, taskIDs[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewQuestRepo(db, TestTimeout).DeleteQuest(ctx, questID, entity.AnyVersion); err != nil {
+		t.Fatalf("DeleteQuest: %v", err)
+	}
+	m, err := NewMigrator(db, schema.Migrations)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := m.Up(); err != nil {
+			t.Errorf("Up: %v", err)
+		}
+	})
+
+	if err = m.Goto(8); err != nil {
+		t.Fatalf("Goto(8): %v", err)
+	}
+	var live []int
+	err = db.Select(&live, `SELECT id FROM tasks WHERE quest_id = $1 AND deleted_at IS NULL ORDER BY id`, questID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(live) != 1 || live[0] != taskIDs[0] {
+		t.Fatalf("live tasks after down = %v, want only %d", live, taskIDs[0])
+	}
+}
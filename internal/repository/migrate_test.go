@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"quest_service/schema"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewMigrator(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000010_later.up.sql":    {Data: []byte("up 10")},
+		"000010_later.down.sql":  {Data: []byte("down 10")},
+		"000002_second.up.sql":   {Data: []byte("up 2")},
+		"000002_second.down.sql": {Data: []byte("down 2")},
+		"000001_init.up.sql":     {Data: []byte("up 1")},
+		"000001_init.down.sql":   {Data: []byte("down 1")},
+		"schema.go":              {Data: []byte("package schema")},
+		"README.md":              {Data: []byte("notes")},
+	}
+	m, err := NewMigrator(nil, fsys)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "init", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+		{Version: 10, Name: "later", Up: "up 10", Down: "down 10"},
+	}
+	if len(m.migrations) != len(want) {
+		t.Fatalf("migrations = %+v, want %+v", m.migrations, want)
+	}
+	for i := range want {
+		if m.migrations[i] != want[i] {
+			t.Errorf("migrations[%d] = %+v, want %+v", i, m.migrations[i], want[i])
+		}
+	}
+
+	if got := m.latest(); got != 10 {
+		t.Errorf("latest() = %d, want 10", got)
+	}
+	if got := m.next(2); got != 10 {
+		t.Errorf("next(2) = %d, want 10", got)
+	}
+	if got := m.next(10); got != 10 {
+		t.Errorf("next(10) = %d, want 10", got)
+	}
+	if got := m.previous(10); got != 2 {
+		t.Errorf("previous(10) = %d, want 2", got)
+	}
+	if got := m.previous(1); got != 0 {
+		t.Errorf("previous(1) = %d, want 0", got)
+	}
+	if got := m.index(3); got != -1 {
+		t.Errorf("index(3) = %d, want -1", got)
+	}
+}
+
+func TestNewMigratorMissingPair(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_init.up.sql":   {Data: []byte("up 1")},
+		"000001_init.down.sql": {Data: []byte("down 1")},
+		"000002_second.up.sql": {Data: []byte("up 2")},
+	}
+	if _, err := NewMigrator(nil, fsys); err == nil {
+		t.Fatal("NewMigrator() error = nil, want error for missing down file")
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := NewMigrator(nil, schema.Migrations)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	// Номера миграций идут подряд: пропуск обычно означает потерянный файл
+	for i, migration := range m.migrations {
+		if migration.Version != i+1 {
+			t.Fatalf("migration %d has version %d, want %d", i, migration.Version, i+1)
+		}
+	}
+}
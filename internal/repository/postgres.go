@@ -1,28 +1,36 @@
 package repository
 
 import (
-	"fmt"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
-	"log"
+	"github.com/uptrace/opentelemetry-go-extra/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"log/slog"
 	"quest_service/configs"
 )
 
+// NewPostgresDB открывает пул соединений. Каждый SQL-запрос записывается в трассу
+// отдельным span с текстом запроса без параметров
 func NewPostgresDB(cfg configs.Config) (*sqlx.DB, error) {
-	db, err := sqlx.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBName, cfg.DBPass, cfg.DBSSLMode))
+	sqlDB, err := otelsql.Open("postgres", cfg.DB.ConnString(),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithDBName(cfg.DB.Name),
+	)
 	if err != nil {
 		return nil, err
 	}
+	db := sqlx.NewDb(sqlDB, "postgres")
+	// Настройка пула соединений
+	db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
 	//
 	err = db.Ping()
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
-	// Создание пула соединений
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
-	//
-	log.Println("Подключение к БД прошло успешно")
+	slog.Info("Подключение к БД прошло успешно", "host", cfg.DB.Host, "db", cfg.DB.Name)
 	return db, nil
 }
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"quest_service/internal/entity"
+	"time"
+)
+
+type HealthRepo struct {
+	db       *sqlx.DB
+	migrator *Migrator
+	timeout  time.Duration
+}
+
+func NewHealthRepo(db *sqlx.DB, migrator *Migrator, timeout time.Duration) *HealthRepo {
+	return &HealthRepo{db: db, migrator: migrator, timeout: timeout}
+}
+
+func (r *HealthRepo) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.db.PingContext(ctx)
+}
+
+func (r *HealthRepo) MigrationStatus(ctx context.Context) (*MigrationStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.migrator.Status(ctx)
+}
+
+func (r *HealthRepo) PoolStats() entity.PoolStats {
+	stats := r.db.Stats()
+	return entity.PoolStats{
+		MaxOpen:      stats.MaxOpenConnections,
+		Open:         stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration.String(),
+	}
+}
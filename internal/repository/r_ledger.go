@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"quest_service/internal/entity"
+	"time"
+)
+
+type LedgerRepo struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+func NewLedgerRepo(db *sqlx.DB, timeout time.Duration) *LedgerRepo {
+	return &LedgerRepo{db: db, timeout: timeout}
+}
+
+// CreateEntry записывает ручную проводку и пересчитывает баланс пользователя
+func (r *LedgerRepo) CreateEntry(ctx context.Context, input *entity.LedgerEntryInput) (*entity.LedgerEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var entry *entity.LedgerEntry
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		entry, err = addLedgerEntry(ctx, tx, input.UserID, input.Amount, input.Reason, input.SourceID)
+		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return entry, nil
+}
+
+func (r *LedgerRepo) GetEntriesByUserID(ctx context.Context, userID int, page entity.Page) ([]entity.LedgerEntry, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT count(*) FROM ledger_entries WHERE account = 'user' AND user_id = $1`, userID)
+	if err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+
+	entries := []entity.LedgerEntry{}
+	entriesQuery := `
+		SELECT id, user_id, amount, balance_after, reason, source_id, created_at, transaction_id
+		FROM ledger_entries
+		WHERE account = 'user' AND user_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`
+	err = r.db.SelectContext(ctx, &entries, entriesQuery, userID, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+	return entries, total, nil
+}
+
+// addLedgerEntry — единственный способ изменить users.balance.
+// Баланс является проекцией журнала и обновляется в той же транзакции, что и проводка.
+// Проводка по счёту пользователя записывается вместе со встречной проводкой на системном счёте,
+// сумма проводок операции равна нулю — это проверяет триггер ledger_entries_balanced
+func addLedgerEntry(ctx context.Context, tx *sqlx.Tx, userID, amount int, reason string, sourceID *int) (*entity.LedgerEntry, error) {
+	var balance int
+	err := tx.GetContext(ctx, &balance, `UPDATE users SET balance = balance + $2 WHERE id = $1 RETURNING balance`, userID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry entity.LedgerEntry
+	entryQuery := `
+		INSERT INTO ledger_entries (account, user_id, amount, balance_after, reason, source_id)
+		VALUES ('user', $1, $2, $3, $4, $5)
+		RETURNING id, user_id, amount, balance_after, reason, source_id, created_at, transaction_id
+	`
+	err = tx.GetContext(ctx, &entry, entryQuery, userID, amount, balance, reason, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	contraQuery := `
+		INSERT INTO ledger_entries (account, amount, reason, source_id, created_at, transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = tx.ExecContext(ctx, contraQuery, contraAccount(reason), -amount, reason, sourceID, entry.CreatedAt, entry.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// contraAccount возвращает системный счёт, уравновешивающий проводку с основанием reason
+func contraAccount(reason string) string {
+	if reason == entity.LedgerReasonPurchase {
+		return entity.LedgerAccountPurchases
+	}
+	return entity.LedgerAccountPayouts
+}
@@ -0,0 +1,109 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"github.com/lib/pq"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/service"
+	"testing"
+)
+
+// Баланс совпадает с суммой проводок по счёту пользователя, а каждая операция
+// уравновешена проводкой на системном счёте
+func TestLedgerBalanceProjection(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	userID := repository.CreateTestUser(t, db)
+	_, taskIDs := repository.CreateTestQuest(t, db, 50, repository.TestTask{Cost: 7, IsReusable: true})
+	tasks := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout))
+	for i := 0; i < 3; i++ {
+		if _, err := tasks.TaskCompletion(context.Background(), &entity.TaskProgress{UserID: userID, TaskID: taskIDs[0]}); err != nil {
+			t.Fatalf("TaskCompletion: %v", err)
+		}
+	}
+	ledger := repository.NewLedgerRepo(db, repository.TestTimeout)
+	for _, input := range []entity.LedgerEntryInput{
+		{UserID: userID, Amount: 30, Reason: entity.LedgerReasonAdmin},
+		{UserID: userID, Amount: -20, Reason: entity.LedgerReasonPurchase},
+	} {
+		if _, err := ledger.CreateEntry(context.Background(), &input); err != nil {
+			t.Fatalf("CreateEntry: %v", err)
+		}
+	}
+
+	var balance, sum int
+	if err := db.Get(&balance, `SELECT balance FROM users WHERE id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Get(&sum, `SELECT sum(amount) FROM ledger_entries WHERE account = 'user' AND user_id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+	// Три выполнения по 7, бонус за квест 50, корректировка 30, покупка 20
+	if want := 3*7 + 50 + 30 - 20; balance != want || sum != want {
+		t.Fatalf("balance = %d, sum of entries = %d, want %d", balance, sum, want)
+	}
+
+	entries, total, err := ledger.GetEntriesByUserID(context.Background(), userID, entity.Page{Limit: 2})
+	if err != nil {
+		t.Fatalf("GetEntriesByUserID: %v", err)
+	}
+	if total != 6 || len(entries) != 2 || entries[0].BalanceAfter != balance {
+		t.Fatalf("total = %d, entries = %+v, want 6 entries, newest with balance %d", total, entries, balance)
+	}
+
+	var unbalanced int
+	err = db.Get(&unbalanced, `
+		SELECT count(*) FROM (
+			SELECT transaction_id FROM ledger_entries
+			WHERE transaction_id IN (SELECT transaction_id FROM ledger_entries WHERE user_id = $1)
+			GROUP BY transaction_id
+			HAVING sum(amount) <> 0 OR count(*) <> 2
+		) t
+	`, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unbalanced != 0 {
+		t.Fatalf("unbalanced transactions = %d, want 0", unbalanced)
+	}
+
+	var purchases int
+	err = db.Get(&purchases, `
+		SELECT count(*) FROM ledger_entries
+		WHERE account = 'purchases' AND amount = 20
+		  AND transaction_id IN (SELECT transaction_id FROM ledger_entries WHERE user_id = $1)
+	`, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purchases != 1 {
+		t.Fatalf("purchases entries = %d, want 1", purchases)
+	}
+}
+
+// Проводка без встречной отклоняется при фиксации транзакции
+func TestLedgerRejectsUnbalancedTransaction(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	userID := repository.CreateTestUser(t, db)
+
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO ledger_entries (account, user_id, amount, balance_after, reason)
+		VALUES ('user', $1, 10, 10, 'admin')
+	`, userID)
+	if err != nil {
+		tx.Rollback()
+		t.Fatal(err)
+	}
+	err = tx.Commit()
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Constraint != "ledger_entries_balanced" {
+		t.Fatalf("Commit() error = %v, want ledger_entries_balanced violation", err)
+	}
+}
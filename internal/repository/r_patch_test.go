@@ -0,0 +1,163 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"testing"
+	"time"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// Поля, отсутствующие в PATCH, не меняются
+func TestUpdateQuestPartial(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	questID, _ := repository.CreateTestQuest(t, db, 40, repository.TestTask{Cost: 5})
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+
+	quest, err := quests.UpdateQuest(ctx, questID, entity.AnyVersion, &entity.QuestPatch{Name: ptr("renamed")})
+	if err != nil {
+		t.Fatalf("UpdateQuest(name): %v", err)
+	}
+	if quest.Name != "renamed" || quest.Cost != 40 || len(quest.Tasks) != 1 {
+		t.Fatalf("quest = %+v, want renamed quest with cost 40 and its task", quest)
+	}
+
+	quest, err = quests.UpdateQuest(ctx, questID, entity.AnyVersion, &entity.QuestPatch{Cost: ptr(0)})
+	if err != nil {
+		t.Fatalf("UpdateQuest(cost): %v", err)
+	}
+	// Нулевое значение — явное изменение, а не пропуск поля
+	if quest.Name != "renamed" || quest.Cost != 0 {
+		t.Fatalf("quest = %+v, want name kept and cost 0", quest)
+	}
+}
+
+func TestUpdateTaskPartial(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	_, taskIDs := repository.CreateTestQuest(t, db, 40, repository.TestTask{Cost: 5, IsReusable: true})
+	tasks := repository.NewTaskRepo(db, repository.TestTimeout)
+
+	task, err := tasks.UpdateTask(ctx, taskIDs[0], entity.AnyVersion, &entity.TaskPatch{IsReusable: ptr(false)})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if task.IsReusable || task.Cost != 5 || task.Name != "test" {
+		t.Fatalf("task = %+v, want only is_reusable changed", task)
+	}
+}
+
+// Удалённый квест, удалённое задание и задание удалённого квеста не обновляются
+func TestUpdateDeleted(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	tasks := repository.NewTaskRepo(db, repository.TestTimeout)
+
+	_, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	if _, err := db.Exec(`UPDATE tasks SET deleted_at = NOW() WHERE id = $1`, taskIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tasks.UpdateTask(ctx, taskIDs[0], entity.AnyVersion, &entity.TaskPatch{Cost: ptr(2)}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("UpdateTask(deleted task) error = %v, want ErrNotFound", err)
+	}
+
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	if _, err := db.Exec(`UPDATE quests SET deleted_at = NOW() WHERE id = $1`, questID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := quests.UpdateQuest(ctx, questID, entity.AnyVersion, &entity.QuestPatch{Cost: ptr(2)}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("UpdateQuest(deleted quest) error = %v, want ErrNotFound", err)
+	}
+	if _, err := tasks.UpdateTask(ctx, taskIDs[0], entity.AnyVersion, &entity.TaskPatch{Cost: ptr(2)}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("UpdateTask(task of deleted quest) error = %v, want ErrNotFound", err)
+	}
+
+	var cost int
+	if err := db.Get(&cost, `SELECT cost FROM tasks WHERE id = $1`, taskIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if cost != 1 {
+		t.Fatalf("cost of task in deleted quest = %d, want unchanged 1", cost)
+	}
+}
+
+// Изменение с устаревшей версией отклоняется, изменение задания меняет и версию квеста
+func TestUpdateVersionMismatch(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	tasks := repository.NewTaskRepo(db, repository.TestTimeout)
+
+	quest, err := quests.GetQuestByID(ctx, questID, entity.QuestVisibility{})
+	if err != nil {
+		t.Fatalf("GetQuestByID: %v", err)
+	}
+	task, err := tasks.UpdateTask(ctx, taskIDs[0], quest.Tasks[0].Version, &entity.TaskPatch{Cost: ptr(2)})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if task.Version != quest.Tasks[0].Version+1 {
+		t.Fatalf("task version = %d, want %d", task.Version, quest.Tasks[0].Version+1)
+	}
+	if _, err = tasks.UpdateTask(ctx, taskIDs[0], quest.Tasks[0].Version, &entity.TaskPatch{Cost: ptr(3)}); !errors.Is(err, repository.ErrVersionMismatch) {
+		t.Fatalf("UpdateTask(stale version) error = %v, want ErrVersionMismatch", err)
+	}
+
+	if _, err = quests.UpdateQuest(ctx, questID, quest.Version, &entity.QuestPatch{Cost: ptr(20)}); !errors.Is(err, repository.ErrVersionMismatch) {
+		t.Fatalf("UpdateQuest(version before task change) error = %v, want ErrVersionMismatch", err)
+	}
+	updated, err := quests.UpdateQuest(ctx, questID, quest.Version+1, &entity.QuestPatch{Cost: ptr(20)})
+	if err != nil {
+		t.Fatalf("UpdateQuest: %v", err)
+	}
+	if updated.Version != quest.Version+2 || updated.Cost != 20 || updated.Tasks[0].Cost != 2 {
+		t.Fatalf("quest = %+v, want version %d, cost 20, task cost 2", updated, quest.Version+2)
+	}
+}
+
+// Явный null очищает границу окна, отсутствующая граница не меняется
+func TestUpdateQuestWindow(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	questID, _ := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	end := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	quest, err := quests.UpdateQuest(ctx, questID, entity.AnyVersion, &entity.QuestPatch{
+		StartsAt: entity.OptionalTime{Set: true, Time: &start},
+		EndsAt:   entity.OptionalTime{Set: true, Time: &end},
+	})
+	if err != nil {
+		t.Fatalf("UpdateQuest(window): %v", err)
+	}
+	if quest.StartsAt == nil || !quest.StartsAt.Equal(start) || quest.EndsAt == nil || !quest.EndsAt.Equal(end) {
+		t.Fatalf("window = %v..%v, want %v..%v", quest.StartsAt, quest.EndsAt, start, end)
+	}
+
+	quest, err = quests.UpdateQuest(ctx, questID, entity.AnyVersion, &entity.QuestPatch{StartsAt: entity.OptionalTime{Set: true}})
+	if err != nil {
+		t.Fatalf("UpdateQuest(starts_at: null): %v", err)
+	}
+	if quest.StartsAt != nil || quest.EndsAt == nil || !quest.EndsAt.Equal(end) {
+		t.Fatalf("window = %v..%v, want nil..%v", quest.StartsAt, quest.EndsAt, end)
+	}
+
+	quest, err = quests.UpdateQuest(ctx, questID, entity.AnyVersion, &entity.QuestPatch{EndsAt: entity.OptionalTime{Set: true}})
+	if err != nil {
+		t.Fatalf("UpdateQuest(ends_at: null): %v", err)
+	}
+	if quest.StartsAt != nil || quest.EndsAt != nil {
+		t.Fatalf("window = %v..%v, want unbounded", quest.StartsAt, quest.EndsAt)
+	}
+}
@@ -1,138 +1,566 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"quest_service/internal/entity"
+	"strings"
 	"time"
 )
 
 type QuestRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	timeout time.Duration
 }
 
-func NewQuestRepo(db *sqlx.DB) *QuestRepo {
-	return &QuestRepo{db: db}
+func NewQuestRepo(db *sqlx.DB, timeout time.Duration) *QuestRepo {
+	return &QuestRepo{db: db, timeout: timeout}
 }
 
-func (r *QuestRepo) CreateQuest(quest *entity.QuestInput) (int, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return 0, err
-	}
+func (r *QuestRepo) CreateQuest(ctx context.Context, quest *entity.QuestInput) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
 
 	var questID int
-	createQuestQuery := `INSERT INTO quests (name, cost, created_at) values ($1, $2, $3) RETURNING id`
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		createQuestQuery := `
+			INSERT INTO quests (name, cost, created_at, sequential, starts_at, ends_at)
+			values ($1, $2, $3, $4, $5, $6) RETURNING id
+		`
+		err := tx.GetContext(ctx, &questID, createQuestQuery,
+			quest.Name, quest.Cost, time.Now(), quest.Sequential, quest.StartsAt, quest.EndsAt)
+		if err != nil {
+			return err
+		}
 
-	row := tx.QueryRow(createQuestQuery, quest.Name, quest.Cost, time.Now())
-	err = row.Scan(&questID)
+		// Задания нумеруются в порядке перечисления
+		createTaskQuery := `INSERT INTO tasks (quest_id, name, is_reusable, cost, position) values ($1, $2, $3, $4, $5)`
+		for i, task := range quest.Tasks {
+			_, err = tx.ExecContext(ctx, createTaskQuery, questID, task.Name, task.IsReusable, task.Cost, i+1)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		tx.Rollback()
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
+	return questID, nil
+}
+
+// SeedQuests создаёт тестовые квесты с заданиями в статусе status одной транзакцией.
+// Вставка идёт массивами, поэтому число запросов не зависит от количества квестов.
+// Учитываются только название, стоимость и задания
+func (r *QuestRepo) SeedQuests(ctx context.Context, quests []entity.QuestInput, status string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		// ID выделяются заранее, чтобы связать задания с квестами без опоры на порядок RETURNING
+		var questIDs []int64
+		idsQuery := `SELECT nextval(pg_get_serial_sequence('quests', 'id')) FROM generate_series(1, $1)`
+		err := tx.SelectContext(ctx, &questIDs, idsQuery, len(quests))
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(quests))
+		costs := make([]int64, 0, len(quests))
+		var taskQuestIDs, taskCosts, taskPositions []int64
+		var taskNames []string
+		var taskReusable []bool
+		for i, quest := range quests {
+			names = append(names, quest.Name)
+			costs = append(costs, int64(quest.Cost))
+			for j, task := range quest.Tasks {
+				taskQuestIDs = append(taskQuestIDs, questIDs[i])
+				taskNames = append(taskNames, task.Name)
+				taskReusable = append(taskReusable, task.IsReusable)
+				taskCosts = append(taskCosts, int64(task.Cost))
+				taskPositions = append(taskPositions, int64(j+1))
+			}
+		}
 
-	createTaskQuery := `INSERT INTO tasks (quest_id, name, cost) values ($1, $2, $3)`
-	for _, task := range quest.Tasks {
-		_, err = tx.Exec(createTaskQuery, questID, task.Name, task.Cost)
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO quests (id, name, cost, created_at, status)
+			SELECT id, name, cost, NOW(), $4 FROM unnest($1::int[], $2::text[], $3::int[]) AS q (id, name, cost)
+		`, pq.Array(questIDs), pq.Array(names), pq.Array(costs), status)
 		if err != nil {
-			tx.Rollback()
-			return 0, err
+			return err
 		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO tasks (quest_id, name, is_reusable, cost, position)
+			SELECT * FROM unnest($1::int[], $2::text[], $3::boolean[], $4::int[], $5::int[])
+		`, pq.Array(taskQuestIDs), pq.Array(taskNames), pq.Array(taskReusable), pq.Array(taskCosts), pq.Array(taskPositions))
+		return err
+	})
+	if err != nil {
+		return translateError(ctx, err)
 	}
+	return nil
+}
 
-	return questID, tx.Commit()
+// activeQuestCondition — квест q открыт: текущее время попадает в его окно доступности
+const activeQuestCondition = "(q.starts_at IS NULL OR q.starts_at <= NOW()) AND (q.ends_at IS NULL OR q.ends_at > NOW())"
+
+// publishedQuestCondition — квест q опубликован
+const publishedQuestCondition = "q.status = '" + entity.QuestStatusPublished + "'"
+
+// visibilityConditions возвращает условия на квест q, скрывающие недоступные пользователю квесты
+func visibilityConditions(visibility entity.QuestVisibility) []string {
+	var conditions []string
+	if !visibility.Closed {
+		conditions = append(conditions, activeQuestCondition)
+	}
+	if !visibility.Unpublished {
+		conditions = append(conditions, publishedQuestCondition)
+	}
+	return conditions
 }
 
-func (r *QuestRepo) GetQuestsAndTasks() ([]entity.Quest, error) {
-	type QuestWithTasks struct {
-		QuestID        int    `json:"quest_id,omitempty"`
-		QuestName      string `json:"quest_name,omitempty"`
-		QuestCost      int    `json:"quest_cost,omitempty"`
-		TaskID         int    `json:"task_id,omitempty"`
-		TaskName       string `json:"task_name,omitempty"`
-		TaskIsReusable bool   `json:"task_is_reusable,omitempty"`
-		TaskCost       int    `json:"task_cost,omitempty"`
+// GetQuestByID возвращает квест с заданиями, если он виден с правами visibility
+func (r *QuestRepo) GetQuestByID(ctx context.Context, questID int, visibility entity.QuestVisibility) (*entity.Quest, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var quest entity.Quest
+	conditions := append([]string{"q.id = $1", "q.deleted_at IS NULL"}, visibilityConditions(visibility)...)
+	questQuery := `
+		SELECT q.id, q.name, q.cost, q.created_at, q.status, q.sequential, q.starts_at, q.ends_at, q.version
+		FROM quests q
+		WHERE ` + strings.Join(conditions, " AND ")
+	err := r.db.GetContext(ctx, &quest, questQuery, questID)
+	if err != nil {
+		return nil, translateError(ctx, err)
 	}
-	var questWithTasks []QuestWithTasks
-	// Получение всех квестов и их заданий
-	questsQuery := `SELECT q.id, q.name, q.cost, t.id, t.name, t.is_reusable, t.cost FROM quests q JOIN tasks t ON q.id = t.quest_id WHERE q.deleted_at IS NULL AND t.deleted_at IS NULL`
-	rows, err := r.db.Query(questsQuery)
+
+	quests := []entity.Quest{quest}
+	err = attachTasks(ctx, r.db, quests)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err)
+	}
+	return &quests[0], nil
+}
+
+// questProgressQuery — прогресс пользователя по квестам: выполненные и все живые задания,
+// факт и время завершения квеста из quests_complete, блокировка квестами-условиями.
+// Первый параметр — условия на квест q, второй — пагинация
+const questProgressQuery = `
+	SELECT q.id AS quest_id, q.name AS quest_name,
+	       count(t.id) AS total_tasks,
+	       count(t.id) FILTER (WHERE EXISTS (
+	           SELECT 1 FROM tasks_complete tc WHERE tc.task_id = t.id AND tc.user_id = $1
+	       )) AS completed_tasks,
+	       qc.completed_at IS NOT NULL AS is_completed,
+	       ` + lockedQuestCondition + ` AS is_locked,
+	       qc.completed_at
+	FROM quests q
+	LEFT JOIN tasks t ON t.quest_id = q.id AND t.deleted_at IS NULL
+	LEFT JOIN quests_complete qc ON qc.quest_id = q.id AND qc.user_id = $1
+	WHERE %s
+	GROUP BY q.id, qc.completed_at
+	ORDER BY q.id
+	%s
+`
+
+// GetQuestsProgressByUser возвращает прогресс пользователя по квестам, видимым с правами visibility
+func (r *QuestRepo) GetQuestsProgressByUser(ctx context.Context, userID int, page entity.Page, visibility entity.QuestVisibility) ([]entity.QuestProgress, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	conditions := strings.Join(append([]string{"q.deleted_at IS NULL"}, visibilityConditions(visibility)...), " AND ")
+
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT count(*) FROM quests q WHERE `+conditions)
+	if err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+
+	progress := []entity.QuestProgress{}
+	err = r.db.SelectContext(ctx, &progress, fmt.Sprintf(questProgressQuery, conditions, "LIMIT $2 OFFSET $3"), userID, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+	return progress, total, nil
+}
+
+// GetQuestProgressByUser возвращает прогресс пользователя по квесту, если он виден с правами visibility
+func (r *QuestRepo) GetQuestProgressByUser(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.QuestProgress, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	conditions := append([]string{"q.id = $2", "q.deleted_at IS NULL"}, visibilityConditions(visibility)...)
+
+	var progress entity.QuestProgress
+	err := r.db.GetContext(ctx, &progress, fmt.Sprintf(questProgressQuery, strings.Join(conditions, " AND "), ""), userID, questID)
+	if err != nil {
+		return nil, translateError(ctx, err)
 	}
+	return &progress, nil
+}
+
+// questCursor — позиция последнего квеста страницы. Курсор действителен только для той же сортировки
+type questCursor struct {
+	Sort      string    `json:"s"`
+	Desc      bool      `json:"d"`
+	ID        int       `json:"id"`
+	Cost      int       `json:"c"`
+	CreatedAt time.Time `json:"t"`
+	Name      string    `json:"n"`
+}
+
+var questSortColumns = map[string]string{
+	entity.QuestSortCost:      "q.cost",
+	entity.QuestSortCreatedAt: "q.created_at",
+	entity.QuestSortName:      "q.name",
+}
+
+func (r *QuestRepo) GetQuests(ctx context.Context, filter *entity.QuestFilter) (*entity.QuestPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
 
-	defer rows.Close()
+	sortColumn := questSortColumns[filter.Sort]
+
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
 
-	// Запись данных в структуру
-	for rows.Next() {
-		var q QuestWithTasks
-		err = rows.Scan(&q.QuestID, &q.QuestName, &q.QuestCost, &q.TaskID, &q.TaskName, &q.TaskIsReusable, &q.TaskCost)
+	conditions = append(conditions, "q.deleted_at IS NULL")
+	conditions = append(conditions, visibilityConditions(filter.Visibility)...)
+	if filter.Status != "" {
+		conditions = append(conditions, "q.status = "+arg(filter.Status))
+	}
+	if filter.MinCost != nil {
+		conditions = append(conditions, "q.cost >= "+arg(*filter.MinCost))
+	}
+	if filter.MaxCost != nil {
+		conditions = append(conditions, "q.cost <= "+arg(*filter.MaxCost))
+	}
+	if filter.Name != "" {
+		conditions = append(conditions, "q.name ILIKE '%' || "+arg(escapeLike(filter.Name))+" || '%'")
+	}
+	if filter.HasReusableTasks != nil {
+		reusable := "EXISTS (SELECT 1 FROM tasks t WHERE t.quest_id = q.id AND t.deleted_at IS NULL AND t.is_reusable)"
+		if !*filter.HasReusableTasks {
+			reusable = "NOT " + reusable
+		}
+		conditions = append(conditions, reusable)
+	}
+	if filter.Cursor != "" {
+		condition, err := questCursorCondition(filter, arg)
 		if err != nil {
 			return nil, err
 		}
-		questWithTasks = append(questWithTasks, q)
+		conditions = append(conditions, condition)
 	}
 
-	var quests []entity.Quest
-	var questsIDs []int
+	order := "ASC"
+	if filter.Desc {
+		order = "DESC"
+	}
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	questsQuery := fmt.Sprintf(`
+		SELECT q.id, q.name, q.cost, q.created_at, q.status, q.sequential, q.starts_at, q.ends_at, q.version
+		FROM quests q
+		WHERE %s
+		ORDER BY %s %s, q.id %s
+		LIMIT %s
+	`, strings.Join(conditions, " AND "), sortColumn, order, order, arg(filter.Limit+1))
 
-	for _, q := range questWithTasks {
-		if !contains(questsIDs, q.QuestID) {
-			quests = append(quests, entity.Quest{
-				ID:    q.QuestID,
-				Name:  q.QuestName,
-				Cost:  q.QuestCost,
-				Tasks: []entity.Task{},
-			})
-			questsIDs = append(questsIDs, q.QuestID)
-		}
-		quests[len(quests)-1].Tasks = append(quests[len(quests)-1].Tasks, entity.Task{
-			ID:         q.TaskID,
-			Name:       q.TaskName,
-			IsReusable: q.TaskIsReusable,
-			Cost:       q.TaskCost,
+	quests := []entity.Quest{}
+	err := r.db.SelectContext(ctx, &quests, questsQuery, args...)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+
+	page := &entity.QuestPage{Quests: quests}
+	if len(quests) > filter.Limit {
+		page.Quests = quests[:filter.Limit]
+		last := page.Quests[filter.Limit-1]
+		page.NextCursor = encodeQuestCursor(questCursor{
+			Sort:      filter.Sort,
+			Desc:      filter.Desc,
+			ID:        last.ID,
+			Cost:      last.Cost,
+			CreatedAt: last.CreatedAt,
+			Name:      last.Name,
 		})
 	}
 
-	return quests, nil
+	err = attachTasks(ctx, r.db, page.Quests)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return page, nil
 }
 
-func (r *QuestRepo) UpdateNameQuest(questID int, quest *entity.QuestInput) error {
-	// Обновление названия квеста
-	_, err := r.db.Exec("UPDATE quests SET name = $1 WHERE id = $2", quest.Name, questID)
+// attachTasks загружает задания всех квестов страницы одним запросом
+func attachTasks(ctx context.Context, q sqlx.QueryerContext, quests []entity.Quest) error {
+	if len(quests) == 0 {
+		return nil
+	}
+	questIDs := make([]int64, 0, len(quests))
+	for _, q := range quests {
+		questIDs = append(questIDs, int64(q.ID))
+	}
+
+	var tasks []entity.Task
+	tasksQuery := `
+		SELECT id, quest_id, name, is_reusable, cost, position, version
+		FROM tasks
+		WHERE quest_id = ANY($1) AND deleted_at IS NULL
+		ORDER BY position, id
+	`
+	err := sqlx.SelectContext(ctx, q, &tasks, tasksQuery, pq.Array(questIDs))
 	if err != nil {
 		return err
 	}
 
+	taskIDs := make([]int, 0, len(tasks))
+	for _, t := range tasks {
+		taskIDs = append(taskIDs, t.ID)
+	}
+	dependencies, err := getDependencies(ctx, q, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	tasksByQuest := make(map[int][]entity.Task, len(quests))
+	for _, t := range tasks {
+		t.DependsOn = dependencies[t.ID]
+		tasksByQuest[t.QuestID] = append(tasksByQuest[t.QuestID], t)
+	}
+	for i := range quests {
+		quests[i].Tasks = tasksByQuest[quests[i].ID]
+		if quests[i].Tasks == nil {
+			quests[i].Tasks = []entity.Task{}
+		}
+	}
 	return nil
 }
 
-func (r *QuestRepo) UpdateCostQuest(questID int, quest *entity.QuestInput) error {
-	// Обновление стоимости квеста
-	_, err := r.db.Exec("UPDATE quests SET cost = $1 WHERE id = $2", quest.Cost, questID)
+// questCursorCondition возвращает условие keyset-пагинации: квесты после позиции курсора.
+// При равных значениях поля сортировки порядок определяет q.id
+func questCursorCondition(filter *entity.QuestFilter, arg func(value interface{}) string) (string, error) {
+	cursor, err := decodeQuestCursor(filter.Cursor)
+	if err != nil || cursor.Sort != filter.Sort || cursor.Desc != filter.Desc {
+		return "", ErrInvalidCursor
+	}
+	var value interface{}
+	switch filter.Sort {
+	case entity.QuestSortCost:
+		value = cursor.Cost
+	case entity.QuestSortCreatedAt:
+		value = cursor.CreatedAt
+	case entity.QuestSortName:
+		value = cursor.Name
+	}
+	op := ">"
+	if filter.Desc {
+		op = "<"
+	}
+	return fmt.Sprintf("(%s, q.id) %s (%s, %s)", questSortColumns[filter.Sort], op, arg(value), arg(cursor.ID)), nil
+}
+
+func encodeQuestCursor(cursor questCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeQuestCursor(value string) (*questCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var cursor questCursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
 	}
+	return &cursor, nil
+}
 
-	return nil
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
 
-func (r *QuestRepo) DeleteQuest(questID int) error {
-	// Удаление квеста
-	_, err := r.db.Exec("UPDATE quests SET deleted_at = NOW() WHERE id = $1", questID)
+// UpdateQuest меняет переданные поля квеста одним запросом и возвращает квест с заданиями.
+// Если версия квеста отличается от version, возвращает ErrVersionMismatch
+func (r *QuestRepo) UpdateQuest(ctx context.Context, questID, version int, patch *entity.QuestPatch) (*entity.Quest, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var quest entity.Quest
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockQuestQuery, questID, version)
+		if err != nil {
+			return err
+		}
+		// Границы окна меняются и при явном null, поэтому вместо COALESCE — признак наличия поля
+		updateQuery := `
+			UPDATE quests SET name = COALESCE($2, name), cost = COALESCE($3, cost), sequential = COALESCE($8, sequential),
+			       starts_at = CASE WHEN $4::boolean THEN $5::timestamptz ELSE starts_at END,
+			       ends_at = CASE WHEN $6::boolean THEN $7::timestamptz ELSE ends_at END,
+			       version = version + 1
+			WHERE id = $1
+			RETURNING id, name, cost, created_at, status, sequential, starts_at, ends_at, version
+		`
+		err = tx.GetContext(ctx, &quest, updateQuery, questID, patch.Name, patch.Cost,
+			patch.StartsAt.Set, patch.StartsAt.Time, patch.EndsAt.Set, patch.EndsAt.Time, patch.Sequential)
+		if err != nil {
+			return err
+		}
+		quests := []entity.Quest{quest}
+		if err = attachTasks(ctx, tx, quests); err != nil {
+			return err
+		}
+		quest = quests[0]
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, translateError(ctx, err)
 	}
+	return &quest, nil
+}
 
-	return nil
+// ChangeQuestStatus переводит квест в статус status из одного из статусов from.
+// Если текущий статус квеста не входит в from, возвращает ErrInvalidTransition
+func (r *QuestRepo) ChangeQuestStatus(ctx context.Context, questID, version int, from []string, status string) (*entity.Quest, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var quest entity.Quest
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockQuestQuery, questID, version)
+		if err != nil {
+			return err
+		}
+		statusQuery := `
+			UPDATE quests SET status = $2, version = version + 1
+			WHERE id = $1 AND status = ANY($3)
+			RETURNING id, name, cost, created_at, status, sequential, starts_at, ends_at, version
+		`
+		err = tx.GetContext(ctx, &quest, statusQuery, questID, status, pq.Array(from))
+		// Квест заблокирован lockVersion, значит строки нет только из-за статуса
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidTransition
+		}
+		if err != nil {
+			return err
+		}
+		quests := []entity.Quest{quest}
+		if err = attachTasks(ctx, tx, quests); err != nil {
+			return err
+		}
+		quest = quests[0]
+		return nil
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &quest, nil
+}
+
+// ReorderTasks задаёт порядок заданий квеста. taskIDs должен содержать все живые задания квеста,
+// иначе возвращает ErrSetMismatch
+func (r *QuestRepo) ReorderTasks(ctx context.Context, questID, version int, taskIDs []int) (*entity.Quest, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var quest entity.Quest
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockQuestQuery, questID, version)
+		if err != nil {
+			return err
+		}
+		ids := make([]int64, 0, len(taskIDs))
+		for _, id := range taskIDs {
+			ids = append(ids, int64(id))
+		}
+
+		// Список совпадает с заданиями квеста, если он той же длины и каждое его задание принадлежит квесту
+		var total, matched int
+		countQuery := `
+			SELECT count(*), count(*) FILTER (WHERE id = ANY($2))
+			FROM tasks
+			WHERE quest_id = $1 AND deleted_at IS NULL
+		`
+		err = tx.QueryRowxContext(ctx, countQuery, questID, pq.Array(ids)).Scan(&total, &matched)
+		if err != nil {
+			return err
+		}
+		if total != len(ids) || matched != len(ids) {
+			return ErrSetMismatch
+		}
+
+		reorderQuery := `
+			UPDATE tasks SET position = o.position, version = version + 1
+			FROM unnest($2::int[]) WITH ORDINALITY AS o (id, position)
+			WHERE tasks.id = o.id AND tasks.quest_id = $1 AND tasks.position <> o.position
+		`
+		_, err = tx.ExecContext(ctx, reorderQuery, questID, pq.Array(ids))
+		if err != nil {
+			return err
+		}
+		questQuery := `
+			UPDATE quests SET version = version + 1
+			WHERE id = $1
+			RETURNING id, name, cost, created_at, status, sequential, starts_at, ends_at, version
+		`
+		err = tx.GetContext(ctx, &quest, questQuery, questID)
+		if err != nil {
+			return err
+		}
+		quests := []entity.Quest{quest}
+		if err = attachTasks(ctx, tx, quests); err != nil {
+			return err
+		}
+		quest = quests[0]
+		return nil
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &quest, nil
 }
 
-//func (r *QuestRepo)
+// DeleteQuest удаляет квест вместе с его заданиями. Задания получают ту же отметку deleted_at,
+// по ней RestoreQuest отличает их от удалённых раньше
+func (r *QuestRepo) DeleteQuest(ctx context.Context, questID, version int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
 
-func contains(ds []int, id int) bool {
-	for _, v := range ds {
-		if v == id {
-			return true
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockQuestQuery, questID, version)
+		if err != nil {
+			return err
+		}
+		// Удаление квеста
+		_, err = tx.ExecContext(ctx, "UPDATE quests SET deleted_at = NOW(), version = version + 1 WHERE id = $1", questID)
+		if err != nil {
+			return err
 		}
+		// Удаление заданий квеста с той же отметкой времени
+		deleteTasksQuery := `
+			UPDATE tasks SET deleted_at = (SELECT deleted_at FROM quests WHERE id = $1), version = version + 1
+			WHERE quest_id = $1 AND deleted_at IS NULL
+		`
+		_, err = tx.ExecContext(ctx, deleteTasksQuery, questID)
+		return err
+	})
+	if err != nil {
+		return translateError(ctx, err)
 	}
-	return false
+
+	return nil
 }
@@ -0,0 +1,358 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/service"
+	"strings"
+	"testing"
+)
+
+// Квест возвращается с заданиями, удалённые квесты и задания не видны
+func TestGetQuestAndTaskByID(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	questID, taskIDs := repository.CreateTestQuest(t, db, 30, repository.TestTask{Cost: 5}, repository.TestTask{Cost: 7})
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	tasks := repository.NewTaskRepo(db, repository.TestTimeout)
+
+	quest, err := quests.GetQuestByID(context.Background(), questID, entity.QuestVisibility{})
+	if err != nil {
+		t.Fatalf("GetQuestByID: %v", err)
+	}
+	if quest.ID != questID || quest.Cost != 30 || len(quest.Tasks) != 2 {
+		t.Fatalf("quest = %+v, want quest %d with 2 tasks", quest, questID)
+	}
+
+	if _, err = db.Exec(`UPDATE tasks SET deleted_at = NOW() WHERE id = $1`, taskIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tasks.GetTaskByID(context.Background(), taskIDs[0], entity.QuestVisibility{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetTaskByID(deleted task) error = %v, want ErrNotFound", err)
+	}
+	task, err := tasks.GetTaskByID(context.Background(), taskIDs[1], entity.QuestVisibility{})
+	if err != nil || task.Cost != 7 {
+		t.Fatalf("GetTaskByID = %+v, %v, want task with cost 7", task, err)
+	}
+
+	if _, err = db.Exec(`UPDATE quests SET deleted_at = NOW() WHERE id = $1`, questID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = quests.GetQuestByID(context.Background(), questID, entity.QuestVisibility{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetQuestByID(deleted quest) error = %v, want ErrNotFound", err)
+	}
+	// Задание удалённого квеста тоже недоступно
+	if _, err = tasks.GetTaskByID(context.Background(), taskIDs[1], entity.QuestVisibility{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetTaskByID(task of deleted quest) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetUserByID(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	userID := repository.CreateTestUser(t, db)
+	users := repository.NewUserRepo(db, repository.TestTimeout)
+
+	user, err := users.GetUserByID(context.Background(), userID)
+	if err != nil || user.ID != userID {
+		t.Fatalf("GetUserByID = %+v, %v, want user %d", user, err, userID)
+	}
+	if _, err = users.GetUserByID(context.Background(), -1); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetUserByID(-1) error = %v, want ErrNotFound", err)
+	}
+}
+
+// Прогресс учитывает каждое выполненное задание один раз и не учитывает удалённые задания
+func TestQuestProgressByUser(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	userID := repository.CreateTestUser(t, db)
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10,
+		repository.TestTask{Cost: 1, IsReusable: true}, repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1})
+	tasks := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout))
+	for i := 0; i < 2; i++ {
+		if _, err := tasks.TaskCompletion(context.Background(), &entity.TaskProgress{UserID: userID, TaskID: taskIDs[0]}); err != nil {
+			t.Fatalf("TaskCompletion: %v", err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE tasks SET deleted_at = NOW() WHERE id = $1`, taskIDs[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := service.NewQuestService(repository.NewQuestRepo(db, repository.TestTimeout), repository.NewTaskRepo(db, repository.TestTimeout)).GetQuestProgress(context.Background(), userID, questID, entity.QuestVisibility{})
+	if err != nil {
+		t.Fatalf("GetQuestProgress: %v", err)
+	}
+	if progress.CompletedTasks != 1 || progress.TotalTasks != 2 || progress.Percent != 50 || progress.IsCompleted {
+		t.Fatalf("progress = %+v, want 1 of 2 tasks, 50%%, not completed", progress)
+	}
+	if len(progress.Tasks) != 2 || progress.Tasks[0].CompletedCount != 2 || progress.Tasks[1].IsCompleted {
+		t.Fatalf("tasks = %+v, want reusable task completed twice and second task pending", progress.Tasks)
+	}
+}
+
+// Квест вне окна доступности скрыт от игроков, а его задания нельзя выполнить
+func TestQuestOutsideWindow(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	userID := repository.CreateTestUser(t, db)
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	tasks := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout))
+
+	upcoming, upcomingTasks := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	ended, endedTasks := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	if _, err := db.Exec(`UPDATE quests SET starts_at = NOW() + INTERVAL '1 day' WHERE id = $1`, upcoming); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE quests SET ends_at = NOW() - INTERVAL '1 day' WHERE id = $1`, ended); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, questID := range []int{upcoming, ended} {
+		if _, err := quests.GetQuestByID(ctx, questID, entity.QuestVisibility{}); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetQuestByID(%d) error = %v, want ErrNotFound", questID, err)
+		}
+		if _, err := quests.GetQuestByID(ctx, questID, entity.QuestVisibility{Closed: true}); err != nil {
+			t.Fatalf("GetQuestByID(%d, closed visible): %v", questID, err)
+		}
+	}
+	for _, taskID := range []int{upcomingTasks[0], endedTasks[0]} {
+		_, err := tasks.TaskCompletion(ctx, &entity.TaskProgress{UserID: userID, TaskID: taskID})
+		if !errors.Is(err, service.ErrOutsideWindow) {
+			t.Fatalf("TaskCompletion(%d) error = %v, want ErrOutsideWindow", taskID, err)
+		}
+	}
+}
+
+// Квест проходит жизненный цикл только по разрешённым переходам, а его задания выполняются лишь в опубликованном
+func TestChangeQuestStatus(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	userID := repository.CreateTestUser(t, db)
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	tasks := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout))
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	change := func(status string) error {
+		_, err := quests.ChangeQuestStatus(ctx, questID, entity.AnyVersion, entity.QuestStatusesBefore(status), status)
+		return err
+	}
+	complete := func() error {
+		_, err := tasks.TaskCompletion(ctx, &entity.TaskProgress{UserID: userID, TaskID: taskIDs[0]})
+		return err
+	}
+
+	if err := change(entity.QuestStatusPaused); err != nil {
+		t.Fatalf("published -> paused: %v", err)
+	}
+	if _, err := quests.GetQuestByID(ctx, questID, entity.QuestVisibility{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetQuestByID(paused) error = %v, want ErrNotFound", err)
+	}
+	if err := complete(); !errors.Is(err, service.ErrQuestInactive) {
+		t.Fatalf("TaskCompletion(paused) error = %v, want ErrQuestInactive", err)
+	}
+
+	if err := change(entity.QuestStatusArchived); err != nil {
+		t.Fatalf("paused -> archived: %v", err)
+	}
+	if err := change(entity.QuestStatusPublished); !errors.Is(err, repository.ErrInvalidTransition) {
+		t.Fatalf("archived -> published error = %v, want ErrInvalidTransition", err)
+	}
+	if err := complete(); !errors.Is(err, service.ErrQuestInactive) {
+		t.Fatalf("TaskCompletion(archived) error = %v, want ErrQuestInactive", err)
+	}
+	quest, err := quests.GetQuestByID(ctx, questID, entity.QuestVisibility{Unpublished: true})
+	if err != nil || quest.Status != entity.QuestStatusArchived {
+		t.Fatalf("GetQuestByID(unpublished visible) = %+v, %v, want archived quest", quest, err)
+	}
+}
+
+// Новый порядок должен содержать каждое задание квеста ровно один раз
+func TestReorderTasks(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10,
+		repository.TestTask{Cost: 1}, repository.TestTask{Cost: 2}, repository.TestTask{Cost: 3})
+
+	for _, ids := range [][]int{taskIDs[:2], {taskIDs[0], taskIDs[1], taskIDs[2], -1}} {
+		if _, err := quests.ReorderTasks(ctx, questID, entity.AnyVersion, ids); !errors.Is(err, repository.ErrSetMismatch) {
+			t.Fatalf("ReorderTasks(%v) error = %v, want ErrSetMismatch", ids, err)
+		}
+	}
+
+	want := []int{taskIDs[2], taskIDs[0], taskIDs[1]}
+	quest, err := quests.ReorderTasks(ctx, questID, entity.AnyVersion, want)
+	if err != nil {
+		t.Fatalf("ReorderTasks: %v", err)
+	}
+	for i, task := range quest.Tasks {
+		if task.ID != want[i] || task.Position != i+1 {
+			t.Fatalf("tasks = %+v, want order %v", quest.Tasks, want)
+		}
+	}
+}
+
+// В последовательном квесте задание выполняется только после всех предыдущих
+func TestTaskCompletionSequential(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	userID := repository.CreateTestUser(t, db)
+	tasks := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout))
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1})
+	if _, err := db.Exec(`UPDATE quests SET sequential = TRUE WHERE id = $1`, questID); err != nil {
+		t.Fatal(err)
+	}
+	complete := func(taskID int) error {
+		_, err := tasks.TaskCompletion(ctx, &entity.TaskProgress{UserID: userID, TaskID: taskID})
+		return err
+	}
+
+	if err := complete(taskIDs[1]); !errors.Is(err, service.ErrOutOfOrder) {
+		t.Fatalf("TaskCompletion(second) error = %v, want ErrOutOfOrder", err)
+	}
+	if err := complete(taskIDs[0]); err != nil {
+		t.Fatalf("TaskCompletion(first): %v", err)
+	}
+	if err := complete(taskIDs[1]); err != nil {
+		t.Fatalf("TaskCompletion(second after first): %v", err)
+	}
+}
+
+// Квест создаётся вместе с заданиями в порядке перечисления
+func TestCreateQuest(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+
+	questID, err := quests.CreateQuest(ctx, &entity.QuestInput{
+		Name:  "quest",
+		Cost:  10,
+		Tasks: []entity.TaskInput{{Name: "first", Cost: 1}, {Name: "second", Cost: 2, IsReusable: true}},
+	})
+	if err != nil {
+		t.Fatalf("CreateQuest: %v", err)
+	}
+	quest, err := quests.GetQuestByID(ctx, questID, entity.QuestVisibility{Unpublished: true})
+	if err != nil {
+		t.Fatalf("GetQuestByID: %v", err)
+	}
+	if quest.Status != entity.QuestStatusDraft || len(quest.Tasks) != 2 {
+		t.Fatalf("quest = %+v, want draft with 2 tasks", quest)
+	}
+	if quest.Tasks[0].Name != "first" || quest.Tasks[1].Name != "second" || !quest.Tasks[1].IsReusable {
+		t.Fatalf("tasks = %+v, want first, reusable second", quest.Tasks)
+	}
+}
+
+// Тестовые квесты создаются одной транзакцией с заданиями своих квестов
+func TestSeedQuests(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	input := []entity.QuestInput{
+		{Name: "seed-1", Cost: 10, Tasks: []entity.TaskInput{{Name: "seed-1-a", Cost: 1}, {Name: "seed-1-b", Cost: 2}}},
+		{Name: "seed-2", Cost: 20, Tasks: []entity.TaskInput{{Name: "seed-2-a", Cost: 3, IsReusable: true}}},
+	}
+	if err := quests.SeedQuests(ctx, input, entity.QuestStatusPublished); err != nil {
+		t.Fatalf("SeedQuests: %v", err)
+	}
+
+	var rows []struct {
+		Quest    string `db:"quest"`
+		Status   string `db:"status"`
+		Task     string `db:"task"`
+		Position int    `db:"position"`
+	}
+	err := db.Select(&rows, `
+		SELECT q.name AS quest, q.status, t.name AS task, t.position
+		FROM quests q JOIN tasks t ON t.quest_id = q.id
+		WHERE q.name LIKE 'seed-%'
+		ORDER BY q.name, t.position
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"seed-1-a", "seed-1-b", "seed-2-a"}
+	if len(rows) != len(want) {
+		t.Fatalf("seeded tasks = %+v, want %v", rows, want)
+	}
+	for i, row := range rows {
+		if row.Task != want[i] || !strings.HasPrefix(row.Task, row.Quest) || row.Status != entity.QuestStatusPublished {
+			t.Fatalf("seeded tasks = %+v, want %v in published quests", rows, want)
+		}
+	}
+}
+
+// Прогресс показывает только видимые квесты, и число квестов считается по тем же условиям
+func TestQuestsProgressVisibility(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	userID := repository.CreateTestUser(t, db)
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	paused, _ := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	if _, err := db.Exec(`UPDATE quests SET status = 'paused' WHERE id = $1`, paused); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := quests.GetQuestProgressByUser(ctx, userID, paused, entity.QuestVisibility{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetQuestProgressByUser(paused) error = %v, want ErrNotFound", err)
+	}
+	if _, err := quests.GetQuestProgressByUser(ctx, userID, paused, entity.QuestVisibility{Unpublished: true}); err != nil {
+		t.Fatalf("GetQuestProgressByUser(paused, unpublished visible): %v", err)
+	}
+
+	var visible int
+	err := db.Get(&visible, `
+		SELECT count(*) FROM quests
+		WHERE deleted_at IS NULL AND status = 'published'
+		  AND (starts_at IS NULL OR starts_at <= NOW()) AND (ends_at IS NULL OR ends_at > NOW())
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress, total, err := quests.GetQuestsProgressByUser(ctx, userID, entity.Page{Limit: visible + 1}, entity.QuestVisibility{})
+	if err != nil {
+		t.Fatalf("GetQuestsProgressByUser: %v", err)
+	}
+	if total != visible || len(progress) != visible {
+		t.Fatalf("total = %d, page = %d, want %d visible quests", total, len(progress), visible)
+	}
+	for _, p := range progress {
+		if p.QuestID == paused {
+			t.Fatalf("paused quest %d in progress of a player", paused)
+		}
+	}
+}
+
+// История содержит каждое выполнение пользователя и не содержит заданий из корзины
+func TestUserTasksHistory(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	userID := repository.CreateTestUser(t, db)
+	otherID := repository.CreateTestUser(t, db)
+	_, taskIDs := repository.CreateTestQuest(t, db, 10,
+		repository.TestTask{Cost: 1, IsReusable: true}, repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1})
+	tasks := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout))
+	for _, progress := range []entity.TaskProgress{
+		{UserID: userID, TaskID: taskIDs[0]},
+		{UserID: userID, TaskID: taskIDs[0]},
+		{UserID: userID, TaskID: taskIDs[1]},
+		{UserID: otherID, TaskID: taskIDs[2]},
+	} {
+		if _, err := tasks.TaskCompletion(ctx, &progress); err != nil {
+			t.Fatalf("TaskCompletion(%+v): %v", progress, err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE tasks SET deleted_at = NOW() WHERE id = $1`, taskIDs[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := repository.NewUserRepo(db, repository.TestTimeout).GetUserTasksHistoryByUserID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUserTasksHistoryByUserID: %v", err)
+	}
+	if len(history) != 2 || history[0].ID != taskIDs[0] || history[1].ID != taskIDs[0] {
+		t.Fatalf("history = %+v, want two completions of task %d", history, taskIDs[0])
+	}
+}
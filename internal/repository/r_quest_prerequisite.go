@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"quest_service/internal/entity"
+)
+
+// lockedQuestCondition — у квеста q есть неудалённый квест-условие, который пользователь $1 не завершил.
+// Источник истины — quests_complete
+const lockedQuestCondition = `EXISTS (
+	SELECT 1 FROM quest_prerequisites qp
+	JOIN quests pq ON pq.id = qp.prerequisite_id AND pq.deleted_at IS NULL
+	WHERE qp.quest_id = q.id
+	  AND NOT EXISTS (SELECT 1 FROM quests_complete pc WHERE pc.quest_id = qp.prerequisite_id AND pc.user_id = $1)
+)`
+
+// GetPrerequisites возвращает неудалённые квесты-условия квеста, если он виден с правами visibility
+func (r *QuestRepo) GetPrerequisites(ctx context.Context, questID int, visibility entity.QuestVisibility) (*entity.QuestPrerequisites, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	prerequisites, err := getPrerequisites(ctx, r.db, questID, visibility)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return prerequisites, nil
+}
+
+// SetPrerequisites заменяет квесты-условия квеста. Если новые связи замыкают цепочку в цикл,
+// возвращает ErrCycle, если квест-условие не найден — ErrReferenceMissing
+func (r *QuestRepo) SetPrerequisites(ctx context.Context, questID, version int, prerequisiteIDs []int) (*entity.QuestPrerequisites, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var prerequisites *entity.QuestPrerequisites
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockQuestQuery, questID, version)
+		if err != nil {
+			return err
+		}
+		ids := make([]int64, 0, len(prerequisiteIDs))
+		for _, id := range prerequisiteIDs {
+			ids = append(ids, int64(id))
+		}
+
+		var found int
+		err = tx.GetContext(ctx, &found, `SELECT count(*) FROM quests WHERE id = ANY($1) AND deleted_at IS NULL`, pq.Array(ids))
+		if err != nil {
+			return err
+		}
+		if found != len(ids) {
+			return ErrReferenceMissing
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM quest_prerequisites WHERE quest_id = $1`, questID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO quest_prerequisites (quest_id, prerequisite_id)
+			SELECT $1, unnest($2::int[])
+		`, questID, pq.Array(ids))
+		if err != nil {
+			return err
+		}
+
+		// Цикл есть, если из квеста по цепочке условий можно вернуться в него же.
+		// Сериализуемая транзакция не даст двум встречным изменениям замкнуть цикл одновременно
+		var cycle bool
+		cycleQuery := `
+			WITH RECURSIVE reachable (id) AS (
+				SELECT prerequisite_id FROM quest_prerequisites WHERE quest_id = $1
+				UNION
+				SELECT qp.prerequisite_id FROM quest_prerequisites qp JOIN reachable r ON qp.quest_id = r.id
+			)
+			SELECT EXISTS (SELECT 1 FROM reachable WHERE id = $1)
+		`
+		err = tx.GetContext(ctx, &cycle, cycleQuery, questID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrCycle
+		}
+
+		_, err = tx.ExecContext(ctx, bumpQuestVersionQuery, questID)
+		if err != nil {
+			return err
+		}
+		prerequisites, err = getPrerequisites(ctx, tx, questID, entity.QuestVisibility{Closed: true, Unpublished: true})
+		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return prerequisites, nil
+}
+
+// getPrerequisites читает версию квеста и его неудалённые квесты-условия
+func getPrerequisites(ctx context.Context, q sqlx.QueryerContext, questID int, visibility entity.QuestVisibility) (*entity.QuestPrerequisites, error) {
+	prerequisites := &entity.QuestPrerequisites{QuestID: questID, PrerequisiteIDs: []int{}}
+	questQuery := `SELECT q.version FROM quests q WHERE q.id = $1 AND q.deleted_at IS NULL`
+	for _, condition := range visibilityConditions(visibility) {
+		questQuery += " AND " + condition
+	}
+	err := sqlx.GetContext(ctx, q, &prerequisites.Version, questQuery, questID)
+	if err != nil {
+		return nil, err
+	}
+	prerequisitesQuery := `
+		SELECT qp.prerequisite_id
+		FROM quest_prerequisites qp
+		JOIN quests q ON q.id = qp.prerequisite_id AND q.deleted_at IS NULL
+		WHERE qp.quest_id = $1
+		ORDER BY qp.prerequisite_id
+	`
+	err = sqlx.SelectContext(ctx, q, &prerequisites.PrerequisiteIDs, prerequisitesQuery, questID)
+	if err != nil {
+		return nil, err
+	}
+	return prerequisites, nil
+}
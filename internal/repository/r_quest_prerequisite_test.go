@@ -0,0 +1,80 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/service"
+	"testing"
+)
+
+// Прямой и транзитивный циклы отклоняются, прежние условия при этом сохраняются
+func TestSetPrerequisitesCycle(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	a, _ := repository.CreateTestQuest(t, db, 10)
+	b, _ := repository.CreateTestQuest(t, db, 10)
+	c, _ := repository.CreateTestQuest(t, db, 10)
+
+	if _, err := quests.SetPrerequisites(ctx, a, entity.AnyVersion, []int{b}); err != nil {
+		t.Fatalf("SetPrerequisites(a <- b): %v", err)
+	}
+	if _, err := quests.SetPrerequisites(ctx, b, entity.AnyVersion, []int{a}); !errors.Is(err, repository.ErrCycle) {
+		t.Fatalf("SetPrerequisites(b <- a) error = %v, want ErrCycle", err)
+	}
+
+	if _, err := quests.SetPrerequisites(ctx, b, entity.AnyVersion, []int{c}); err != nil {
+		t.Fatalf("SetPrerequisites(b <- c): %v", err)
+	}
+	if _, err := quests.SetPrerequisites(ctx, c, entity.AnyVersion, []int{a}); !errors.Is(err, repository.ErrCycle) {
+		t.Fatalf("SetPrerequisites(c <- a) error = %v, want ErrCycle", err)
+	}
+	_, err := service.NewQuestService(quests, repository.NewTaskRepo(db, repository.TestTimeout)).
+		SetPrerequisites(ctx, c, entity.AnyVersion, &entity.QuestPrerequisitesInput{PrerequisiteIDs: []int{a}})
+	if !errors.Is(err, service.ErrValidation) {
+		t.Fatalf("QuestService.SetPrerequisites(c <- a) error = %v, want ErrValidation", err)
+	}
+
+	prerequisites, err := quests.GetPrerequisites(ctx, c, entity.QuestVisibility{})
+	if err != nil {
+		t.Fatalf("GetPrerequisites: %v", err)
+	}
+	if len(prerequisites.PrerequisiteIDs) != 0 {
+		t.Fatalf("prerequisites of c = %v, want none after rejected cycle", prerequisites.PrerequisiteIDs)
+	}
+	if _, err = quests.SetPrerequisites(ctx, c, entity.AnyVersion, []int{-1}); !errors.Is(err, repository.ErrReferenceMissing) {
+		t.Fatalf("SetPrerequisites(missing) error = %v, want ErrReferenceMissing", err)
+	}
+}
+
+// Задание квеста открывается только после завершения квестов-условий
+func TestTaskCompletionLockedQuest(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	userID := repository.CreateTestUser(t, db)
+	quests := repository.NewQuestRepo(db, repository.TestTimeout)
+	tasks := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout))
+	first, firstTasks := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	second, secondTasks := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	if _, err := quests.SetPrerequisites(ctx, second, entity.AnyVersion, []int{first}); err != nil {
+		t.Fatalf("SetPrerequisites: %v", err)
+	}
+	complete := func(taskID int) error {
+		_, err := tasks.TaskCompletion(ctx, &entity.TaskProgress{UserID: userID, TaskID: taskID})
+		return err
+	}
+
+	if err := complete(secondTasks[0]); !errors.Is(err, service.ErrQuestLocked) {
+		t.Fatalf("TaskCompletion(locked) error = %v, want ErrQuestLocked", err)
+	}
+	if err := complete(firstTasks[0]); err != nil {
+		t.Fatalf("TaskCompletion(prerequisite): %v", err)
+	}
+	if err := complete(secondTasks[0]); err != nil {
+		t.Fatalf("TaskCompletion(unlocked): %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"quest_service/internal/entity"
+	"testing"
+	"time"
+)
+
+func TestQuestCursorRoundTrip(t *testing.T) {
+	cursor := questCursor{
+		Sort:      entity.QuestSortCreatedAt,
+		Desc:      true,
+		ID:        42,
+		Cost:      100,
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC),
+		Name:      "Квест «один»",
+	}
+	decoded, err := decodeQuestCursor(encodeQuestCursor(cursor))
+	if err != nil {
+		t.Fatalf("decodeQuestCursor: %v", err)
+	}
+	if decoded.Sort != cursor.Sort || decoded.Desc != cursor.Desc || decoded.ID != cursor.ID ||
+		decoded.Cost != cursor.Cost || !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.Name != cursor.Name {
+		t.Fatalf("decoded = %+v, want %+v", decoded, cursor)
+	}
+}
+
+func TestDecodeQuestCursorInvalid(t *testing.T) {
+	for _, value := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := decodeQuestCursor(value); err == nil {
+			t.Errorf("decodeQuestCursor(%q) error = nil, want error", value)
+		}
+	}
+}
+
+// collectArgs возвращает функцию нумерации параметров, как в GetQuests, и собранные значения
+func collectArgs() (func(value interface{}) string, *[]interface{}) {
+	var args []interface{}
+	return func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}, &args
+}
+
+func TestQuestCursorCondition(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		sort  string
+		desc  bool
+		want  string
+		value interface{}
+	}{
+		{entity.QuestSortCost, false, "(q.cost, q.id) > ($1, $2)", 100},
+		{entity.QuestSortCost, true, "(q.cost, q.id) < ($1, $2)", 100},
+		{entity.QuestSortCreatedAt, false, "(q.created_at, q.id) > ($1, $2)", createdAt},
+		{entity.QuestSortName, true, "(q.name, q.id) < ($1, $2)", "name"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s desc=%v", tt.sort, tt.desc), func(t *testing.T) {
+			filter := &entity.QuestFilter{Sort: tt.sort, Desc: tt.desc}
+			filter.Cursor = encodeQuestCursor(questCursor{
+				Sort: tt.sort, Desc: tt.desc, ID: 7, Cost: 100, CreatedAt: createdAt, Name: "name",
+			})
+			arg, args := collectArgs()
+			got, err := questCursorCondition(filter, arg)
+			if err != nil {
+				t.Fatalf("questCursorCondition: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("condition = %q, want %q", got, tt.want)
+			}
+			// Второй параметр — ID последнего квеста: он упорядочивает квесты с одинаковым значением поля
+			if len(*args) != 2 || fmt.Sprint((*args)[0]) != fmt.Sprint(tt.value) || (*args)[1] != 7 {
+				t.Fatalf("args = %v, want [%v 7]", *args, tt.value)
+			}
+		})
+	}
+}
+
+func TestQuestCursorConditionMismatch(t *testing.T) {
+	cursor := encodeQuestCursor(questCursor{Sort: entity.QuestSortCost, ID: 7})
+	tests := []struct {
+		name   string
+		filter entity.QuestFilter
+	}{
+		{"другое поле сортировки", entity.QuestFilter{Sort: entity.QuestSortName, Cursor: cursor}},
+		{"другое направление", entity.QuestFilter{Sort: entity.QuestSortCost, Desc: true, Cursor: cursor}},
+		{"повреждённый курсор", entity.QuestFilter{Sort: entity.QuestSortCost, Cursor: cursor[:len(cursor)-2]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arg, _ := collectArgs()
+			if _, err := questCursorCondition(&tt.filter, arg); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("questCursorCondition() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -1,154 +1,336 @@
 package repository
 
 import (
-	"fmt"
+	"context"
+	"database/sql"
+	"errors"
 	"github.com/jmoiron/sqlx"
-	"log"
 	"quest_service/internal/entity"
+	"quest_service/internal/tracing"
+	"strings"
 	"time"
 )
 
 type TaskRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	timeout time.Duration
 }
 
-func NewTaskRepo(db *sqlx.DB) *TaskRepo {
-	return &TaskRepo{db: db}
+func NewTaskRepo(db *sqlx.DB, timeout time.Duration) *TaskRepo {
+	return &TaskRepo{db: db, timeout: timeout}
 }
 
-func (r *TaskRepo) GetTaskByID(taskID int) (*entity.Task, error) {
+// GetTaskByID возвращает задание, если ни оно, ни его квест не удалены и квест виден с правами visibility
+func (r *TaskRepo) GetTaskByID(ctx context.Context, taskID int, visibility entity.QuestVisibility) (*entity.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var task entity.Task
-	taskQuery := `SELECT * FROM tasks WHERE id = $1`
-	err := r.db.Get(&task, taskQuery, taskID)
+	conditions := append([]string{"t.id = $1", "t.deleted_at IS NULL", "q.deleted_at IS NULL"}, visibilityConditions(visibility)...)
+	taskQuery := `
+		SELECT t.id, t.quest_id, t.name, t.is_reusable, t.cost, t.position, t.version
+		FROM tasks t
+		JOIN quests q ON q.id = t.quest_id
+		WHERE ` + strings.Join(conditions, " AND ")
+	err := r.db.GetContext(ctx, &task, taskQuery, taskID)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err)
 	}
-	return &task, nil
-}
-
-func (r *TaskRepo) GetCountTaskProgress(task *entity.TaskProgress) (int, error) {
-	var countTaskProgress int
-	countTaskProgressQuery := fmt.Sprintf("SELECT count(*) FROM tasks_complete WHERE user_id = $1 AND task_id = $2")
-	err := r.db.Get(&countTaskProgress, countTaskProgressQuery, task.UserID, task.TaskID)
+	dependencies, err := getDependencies(ctx, r.db, []int{task.ID})
 	if err != nil {
-		return 0, err
+		return nil, translateError(ctx, err)
 	}
-	return countTaskProgress, nil
+	task.DependsOn = dependencies[task.ID]
+	return &task, nil
 }
 
-func (r *TaskRepo) GetTaskStatusesByQuestAndUser(questID, userID int) ([]entity.TaskStatus, error) {
-	query := `
-        SELECT t.id, CASE WHEN tp.task_id IS NULL THEN false ELSE true END AS is_completed
-        FROM tasks t
-        LEFT JOIN tasks_complete tp ON t.id = tp.task_id AND tp.user_id = $1
-        WHERE t.quest_id = $2
-    `
-	rows, err := r.db.Query(query, userID, questID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (r *TaskRepo) GetTaskStatusesByQuestAndUser(ctx context.Context, questID, userID int) ([]entity.TaskStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
 
-	var taskStatuses []entity.TaskStatus
-	for rows.Next() {
-		var taskStatus entity.TaskStatus
-		if err := rows.Scan(&taskStatus.TaskID, &taskStatus.IsCompleted); err != nil {
-			return nil, err
-		}
-		taskStatuses = append(taskStatuses, taskStatus)
+	taskStatuses, err := getTaskStatuses(ctx, r.db, questID, userID)
+	if err != nil {
+		return nil, translateError(ctx, err)
 	}
 	return taskStatuses, nil
 }
 
-func (r *TaskRepo) TaskCompletion(userID, taskID, taskCost, questID int, isLastTask bool) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	// Записываем данные о выполнении задания
-	taskCompletionQuery := `INSERT INTO tasks_complete (user_id, task_id, completed_at) values ($1, $2, $3)`
-	_, err = tx.Exec(taskCompletionQuery, userID, taskID, time.Now())
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
+// TaskCompletion выполняет проверку и начисление награды в одной транзакции.
+// Строка пользователя блокируется, поэтому одновременные выполнения одного пользователя
+// идут строго по очереди. decide получает актуальное состояние и решает, завершён ли квест.
+func (r *TaskRepo) TaskCompletion(ctx context.Context, userID, taskID int, decide func(state *entity.TaskCompletionState) (bool, error)) (*entity.TaskCompletionResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var result *entity.TaskCompletionResult
 
-	if isLastTask == true {
-		// Завершаем квест
-		questCompletionQuery := `INSERT INTO quests_complete (user_id, quest_id, completed_at) values ($1, $2, $3)`
-		_, err = tx.Exec(questCompletionQuery, userID, questID, time.Now())
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		stateCtx, span := tracing.Start(ctx, "TaskRepo.getTaskCompletionState", tracing.UserID(userID), tracing.TaskID(taskID))
+		state, err := getTaskCompletionState(stateCtx, tx, userID, taskID)
+		span.End()
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
 
-		// Обновляем баланс пользователя
-		updateBalanceQuery := `UPDATE users SET balance = balance + $2 + (SELECT cost FROM quests WHERE id = $3) WHERE id = $1;`
-		_, err = tx.Exec(updateBalanceQuery, userID, taskCost, questID)
+		isLastTask, err := decide(state)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
-		tx.Commit()
-		return nil
+
+		payoutCtx, span := tracing.Start(ctx, "TaskRepo.completeTask", tracing.UserID(userID), tracing.TaskID(taskID))
+		result, err = completeTask(payoutCtx, tx, userID, state.Task, isLastTask)
+		span.End()
+		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+
+	return result, nil
+}
+
+func getTaskCompletionState(ctx context.Context, tx *sqlx.Tx, userID, taskID int) (*entity.TaskCompletionState, error) {
+	var state entity.TaskCompletionState
+
+	// Блокировка пользователя до конца транзакции
+	var lockedID int
+	err := tx.GetContext(ctx, &lockedID, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
 	}
+	state.UserFound = err == nil
 
-	// Обновляем баланс пользователя
-	updateBalanceQuery := `UPDATE users SET balance = balance + $2  WHERE id = $1;`
-	_, err = tx.Exec(updateBalanceQuery, userID, taskCost)
+	// Удалённое задание и задание закрытого квеста тоже читаются: выполнять их нельзя,
+	// но клиент должен узнать причину
+	var task struct {
+		entity.Task
+		Deleted     bool   `db:"deleted"`
+		QuestStatus string `db:"quest_status"`
+		Sequential  bool   `db:"sequential"`
+		NotStarted  bool   `db:"not_started"`
+		Ended       bool   `db:"ended"`
+		Locked      bool   `db:"locked"`
+	}
+	taskQuery := `
+		SELECT t.id, t.quest_id, t.name, t.is_reusable, t.cost,
+		       t.deleted_at IS NOT NULL OR q.deleted_at IS NOT NULL AS deleted,
+		       q.status AS quest_status, q.sequential,
+		       COALESCE(q.starts_at > NOW(), false) AS not_started,
+		       COALESCE(q.ends_at <= NOW(), false) AS ended,
+		       ` + lockedQuestCondition + ` AS locked
+		FROM tasks t
+		JOIN quests q ON q.id = t.quest_id
+		WHERE t.id = $2
+	`
+	err = tx.GetContext(ctx, &task, taskQuery, userID, taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &state, nil
+	}
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, err
+	}
+	state.Task = &task.Task
+	state.Deleted = task.Deleted
+	state.QuestStatus = task.QuestStatus
+	state.QuestSequential = task.Sequential
+	state.QuestNotStarted = task.NotStarted
+	state.QuestEnded = task.Ended
+	state.QuestLocked = task.Locked
+	if state.Deleted || state.QuestStatus != entity.QuestStatusPublished || state.QuestNotStarted || state.QuestEnded || state.QuestLocked {
+		return &state, nil
 	}
 
-	err = tx.Commit()
+	countTaskProgressQuery := `SELECT count(*) FROM tasks_complete WHERE user_id = $1 AND task_id = $2`
+	err = tx.GetContext(ctx, &state.CompletedCount, countTaskProgressQuery, userID, taskID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
 
+	state.TaskStatuses, err = getTaskStatuses(ctx, tx, task.QuestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	dependencies, err := getDependencies(ctx, tx, []int{taskID})
+	if err != nil {
+		return nil, err
+	}
+	state.DependsOn = dependencies[taskID]
+
+	return &state, nil
 }
 
-func (r *TaskRepo) CreateTask(task *entity.TaskInput) (int, error) {
-	var taskID int
-	taskQuery := `INSERT INTO tasks (name, cost, quest_id, is_reusable) values ($1, $2, $3, $4) RETURNING id`
-	log.Println(task.QuestID)
-	err := r.db.Get(&taskID, taskQuery, task.Name, task.Cost, task.QuestID, task.IsReusable)
+func getTaskStatuses(ctx context.Context, q sqlx.QueryerContext, questID, userID int) ([]entity.TaskStatus, error) {
+	query := `
+        SELECT t.id AS task_id, t.name, t.is_reusable, t.position,
+               count(tp.task_id) > 0 AS is_completed,
+               count(tp.task_id) AS completed_count,
+               max(tp.completed_at) AS last_completed_at
+        FROM tasks t
+        LEFT JOIN tasks_complete tp ON tp.task_id = t.id AND tp.user_id = $1
+        WHERE t.quest_id = $2 AND t.deleted_at IS NULL
+        GROUP BY t.id
+        ORDER BY t.position, t.id
+    `
+	var taskStatuses []entity.TaskStatus
+	err := sqlx.SelectContext(ctx, q, &taskStatuses, query, userID, questID)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	return taskID, nil
+	return taskStatuses, nil
 }
 
-func (r *TaskRepo) UpdateNameTask(taskID int, name string) error {
-	_, err := r.db.Exec("UPDATE tasks SET name = $1 WHERE id = $2", name, taskID)
+func completeTask(ctx context.Context, tx *sqlx.Tx, userID int, task *entity.Task, isLastTask bool) (*entity.TaskCompletionResult, error) {
+	result := &entity.TaskCompletionResult{
+		TaskID:  task.ID,
+		QuestID: task.QuestID,
+		Reward:  task.Cost,
+	}
+	// Записываем данные о выполнении задания
+	taskCompletionQuery := `INSERT INTO tasks_complete (user_id, task_id, completed_at, is_reusable) values ($1, $2, $3, $4)`
+	_, err := tx.ExecContext(ctx, taskCompletionQuery, userID, task.ID, time.Now(), task.IsReusable)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	if isLastTask {
+		// Завершаем квест. Бонус начисляется только при первой записи о завершении
+		questCompletionQuery := `
+			INSERT INTO quests_complete (user_id, quest_id, completed_at) values ($1, $2, $3)
+			ON CONFLICT (user_id, quest_id) DO NOTHING
+			RETURNING (SELECT COALESCE(cost, 0) FROM quests WHERE id = $2)
+		`
+		err = tx.GetContext(ctx, &result.QuestReward, questCompletionQuery, userID, task.QuestID, time.Now())
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		result.QuestCompleted = err == nil
+	}
+
+	// Начисляем награды через журнал проводок
+	if result.Reward > 0 {
+		_, err = addLedgerEntry(ctx, tx, userID, result.Reward, entity.LedgerReasonTask, &task.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if result.QuestReward > 0 {
+		_, err = addLedgerEntry(ctx, tx, userID, result.QuestReward, entity.LedgerReasonQuestBonus, &task.QuestID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
 }
 
-func (r *TaskRepo) UpdateCostTask(taskID int, cost int) error {
-	_, err := r.db.Exec("UPDATE tasks SET cost = $1 WHERE id = $2", cost, taskID)
-	if err != nil {
+// CreateTask добавляет задание в конец квеста. Если квеста нет, возвращает ErrNotFound,
+// если квест в корзине — ErrParentDeleted
+func (r *TaskRepo) CreateTask(ctx context.Context, task *entity.TaskInput) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var taskID int
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		// Квест блокируется до конца транзакции: удаление квеста дождётся нового задания
+		// и унесёт его в корзину вместе с остальными
+		var questDeleted bool
+		questQuery := `SELECT deleted_at IS NOT NULL FROM quests WHERE id = $1 FOR UPDATE`
+		err := tx.GetContext(ctx, &questDeleted, questQuery, task.QuestID)
+		if err != nil {
+			return err
+		}
+		if questDeleted {
+			return ErrParentDeleted
+		}
+
+		// Новое задание встаёт в конец квеста
+		taskQuery := `
+			INSERT INTO tasks (name, cost, quest_id, is_reusable, position)
+			SELECT $1, $2, $3, $4, COALESCE(max(position), 0) + 1 FROM tasks WHERE quest_id = $3
+			RETURNING id
+		`
+		err = tx.GetContext(ctx, &taskID, taskQuery, task.Name, task.Cost, task.QuestID, task.IsReusable)
+		if err != nil {
+			return err
+		}
+		if len(task.DependsOn) > 0 {
+			if err = setDependencies(ctx, tx, taskID, task.QuestID, task.DependsOn); err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, bumpQuestVersionQuery, task.QuestID)
 		return err
+	})
+	if err != nil {
+		return 0, translateError(ctx, err)
 	}
-	return nil
+	return taskID, nil
 }
 
-func (r *TaskRepo) UpdateIsReusableTask(taskID int, isReusable bool) error {
-	_, err := r.db.Exec("UPDATE tasks SET is_reusable = $1 WHERE id = $2", isReusable, taskID)
-	if err != nil {
+// UpdateTask меняет переданные поля задания одним запросом. Задание удалённого квеста не обновляется.
+// Если версия задания отличается от version, возвращает ErrVersionMismatch, если новые зависимости
+// замыкают цикл — ErrCycle
+func (r *TaskRepo) UpdateTask(ctx context.Context, taskID, version int, patch *entity.TaskPatch) (*entity.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var task entity.Task
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockTaskQuery, taskID, version)
+		if err != nil {
+			return err
+		}
+		updateQuery := `
+			UPDATE tasks
+			SET name = COALESCE($2, name), is_reusable = COALESCE($3, is_reusable), cost = COALESCE($4, cost),
+			    version = version + 1
+			WHERE id = $1
+			RETURNING id, quest_id, name, is_reusable, cost, position, version
+		`
+		err = tx.GetContext(ctx, &task, updateQuery, taskID, patch.Name, patch.IsReusable, patch.Cost)
+		if err != nil {
+			return err
+		}
+		if patch.DependsOn != nil {
+			if err = setDependencies(ctx, tx, taskID, task.QuestID, *patch.DependsOn); err != nil {
+				return err
+			}
+		}
+		dependencies, err := getDependencies(ctx, tx, []int{taskID})
+		if err != nil {
+			return err
+		}
+		task.DependsOn = dependencies[taskID]
+		_, err = tx.ExecContext(ctx, bumpQuestVersionQuery, task.QuestID)
 		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
 	}
-	return nil
+	return &task, nil
 }
 
-func (r *TaskRepo) DeleteTask(taskID int) error {
-	// Удаление задания
-	_, err := r.db.Exec("UPDATE tasks SET deleted_at = NOW() WHERE id = $1", taskID)
-	if err != nil {
+func (r *TaskRepo) DeleteTask(ctx context.Context, taskID, version int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockTaskQuery, taskID, version)
+		if err != nil {
+			return err
+		}
+		// Удаление задания
+		var questID int
+		deleteQuery := `UPDATE tasks SET deleted_at = NOW(), version = version + 1 WHERE id = $1 RETURNING quest_id`
+		err = tx.GetContext(ctx, &questID, deleteQuery, taskID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, bumpQuestVersionQuery, questID)
 		return err
+	})
+	if err != nil {
+		return translateError(ctx, err)
 	}
 
 	return nil
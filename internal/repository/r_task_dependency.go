@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"quest_service/internal/entity"
+	"strings"
+)
+
+// getDependencies возвращает для каждого задания из taskIDs его неудалённые зависимости
+func getDependencies(ctx context.Context, q sqlx.QueryerContext, taskIDs []int) (map[int][]int, error) {
+	dependencies := make(map[int][]int, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return dependencies, nil
+	}
+	ids := make([]int64, 0, len(taskIDs))
+	for _, id := range taskIDs {
+		ids = append(ids, int64(id))
+	}
+
+	var edges []struct {
+		TaskID      int `db:"task_id"`
+		DependsOnID int `db:"depends_on_id"`
+	}
+	edgesQuery := `
+		SELECT d.task_id, d.depends_on_id
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.depends_on_id AND t.deleted_at IS NULL
+		WHERE d.task_id = ANY($1)
+		ORDER BY t.position, t.id
+	`
+	err := sqlx.SelectContext(ctx, q, &edges, edgesQuery, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range edges {
+		dependencies[e.TaskID] = append(dependencies[e.TaskID], e.DependsOnID)
+	}
+	return dependencies, nil
+}
+
+// setDependencies заменяет зависимости задания. Зависеть можно только от живых заданий того же квеста,
+// иначе ErrReferenceMissing. Если новые связи замыкают цикл, возвращает ErrCycle
+func setDependencies(ctx context.Context, tx *sqlx.Tx, taskID, questID int, dependsOn []int) error {
+	ids := make([]int64, 0, len(dependsOn))
+	for _, id := range dependsOn {
+		ids = append(ids, int64(id))
+	}
+
+	var found int
+	foundQuery := `SELECT count(*) FROM tasks WHERE id = ANY($1) AND quest_id = $2 AND deleted_at IS NULL`
+	err := tx.GetContext(ctx, &found, foundQuery, pq.Array(ids), questID)
+	if err != nil {
+		return err
+	}
+	if found != len(ids) {
+		return ErrReferenceMissing
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM task_dependencies WHERE task_id = $1`, taskID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_dependencies (task_id, depends_on_id)
+		SELECT $1, unnest($2::int[])
+	`, taskID, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	// Цикл есть, если из задания по цепочке зависимостей можно вернуться в него же.
+	// Рёбра через задания в корзине не учитываются: такие зависимости не действуют
+	var cycle bool
+	cycleQuery := `
+		WITH RECURSIVE reachable (id) AS (
+			SELECT d.depends_on_id FROM task_dependencies d
+			JOIN tasks t ON t.id = d.depends_on_id AND t.deleted_at IS NULL
+			WHERE d.task_id = $1
+			UNION
+			SELECT d.depends_on_id FROM task_dependencies d
+			JOIN reachable r ON d.task_id = r.id
+			JOIN tasks t ON t.id = d.depends_on_id AND t.deleted_at IS NULL
+		)
+		SELECT EXISTS (SELECT 1 FROM reachable WHERE id = $1)
+	`
+	err = tx.GetContext(ctx, &cycle, cycleQuery, taskID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrCycle
+	}
+	return nil
+}
+
+// GetTaskGraph возвращает задания квеста с зависимостями и прогрессом пользователя,
+// если квест виден с правами visibility. Доступность заданий не вычисляется
+func (r *TaskRepo) GetTaskGraph(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.TaskGraph, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var graph entity.TaskGraph
+	conditions := append([]string{"q.id = $2", "q.deleted_at IS NULL"}, visibilityConditions(visibility)...)
+	questQuery := `
+		SELECT q.id, q.sequential,
+		       ` + publishedQuestCondition + ` AND ` + activeQuestCondition + ` AND NOT ` + lockedQuestCondition + ` AS open
+		FROM quests q
+		WHERE ` + strings.Join(conditions, " AND ")
+	err := r.db.GetContext(ctx, &graph, questQuery, userID, questID)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+
+	statuses, err := getTaskStatuses(ctx, r.db, questID, userID)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	taskIDs := make([]int, 0, len(statuses))
+	for _, s := range statuses {
+		taskIDs = append(taskIDs, s.TaskID)
+	}
+	dependencies, err := getDependencies(ctx, r.db, taskIDs)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+
+	graph.Nodes = make([]entity.TaskGraphNode, 0, len(statuses))
+	for _, s := range statuses {
+		dependsOn := dependencies[s.TaskID]
+		if dependsOn == nil {
+			dependsOn = []int{}
+		}
+		graph.Nodes = append(graph.Nodes, entity.TaskGraphNode{TaskStatus: s, DependsOn: dependsOn})
+	}
+	return &graph, nil
+}
@@ -0,0 +1,102 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/service"
+	"testing"
+)
+
+// Прямой и транзитивный циклы зависимостей и зависимость от чужого задания отклоняются
+func TestSetDependenciesRejected(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	tasks := repository.NewTaskRepo(db, repository.TestTimeout)
+	_, taskIDs := repository.CreateTestQuest(t, db, 10,
+		repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1})
+	_, otherTasks := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	dependOn := func(taskID int, ids ...int) error {
+		_, err := tasks.UpdateTask(ctx, taskID, entity.AnyVersion, &entity.TaskPatch{DependsOn: &ids})
+		return err
+	}
+
+	if err := dependOn(taskIDs[1], taskIDs[0]); err != nil {
+		t.Fatalf("UpdateTask(2 <- 1): %v", err)
+	}
+	if err := dependOn(taskIDs[0], taskIDs[1]); !errors.Is(err, repository.ErrCycle) {
+		t.Fatalf("UpdateTask(1 <- 2) error = %v, want ErrCycle", err)
+	}
+	if err := dependOn(taskIDs[2], taskIDs[1]); err != nil {
+		t.Fatalf("UpdateTask(3 <- 2): %v", err)
+	}
+	if err := dependOn(taskIDs[0], taskIDs[2]); !errors.Is(err, repository.ErrCycle) {
+		t.Fatalf("UpdateTask(1 <- 3) error = %v, want ErrCycle", err)
+	}
+	if err := dependOn(taskIDs[0], otherTasks[0]); !errors.Is(err, repository.ErrReferenceMissing) {
+		t.Fatalf("UpdateTask(other quest) error = %v, want ErrReferenceMissing", err)
+	}
+}
+
+// Задание выполняется после своих зависимостей, граф отмечает доступные задания
+func TestTaskCompletionDependencies(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	userID := repository.CreateTestUser(t, db)
+	repo := repository.NewTaskRepo(db, repository.TestTimeout)
+	tasks := service.NewTaskService(repo)
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1})
+	if _, err := repo.UpdateTask(ctx, taskIDs[0], entity.AnyVersion, &entity.TaskPatch{DependsOn: &[]int{taskIDs[1]}}); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	complete := func(taskID int) error {
+		_, err := tasks.TaskCompletion(ctx, &entity.TaskProgress{UserID: userID, TaskID: taskID})
+		return err
+	}
+
+	graph, err := tasks.GetTaskGraph(ctx, userID, questID, entity.QuestVisibility{})
+	if err != nil {
+		t.Fatalf("GetTaskGraph: %v", err)
+	}
+	if len(graph.Nodes) != 2 || graph.Nodes[0].Available || !graph.Nodes[1].Available {
+		t.Fatalf("graph nodes = %+v, want only the second task available", graph.Nodes)
+	}
+	if err = complete(taskIDs[0]); !errors.Is(err, service.ErrOutOfOrder) {
+		t.Fatalf("TaskCompletion(dependent) error = %v, want ErrOutOfOrder", err)
+	}
+	if err = complete(taskIDs[1]); err != nil {
+		t.Fatalf("TaskCompletion(dependency): %v", err)
+	}
+	if err = complete(taskIDs[0]); err != nil {
+		t.Fatalf("TaskCompletion(dependent after dependency): %v", err)
+	}
+}
+
+// Зависимости через задание в корзине не действуют и не замыкают цикл
+func TestSetDependenciesIgnoresTrashed(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	tasks := repository.NewTaskRepo(db, repository.TestTimeout)
+	_, taskIDs := repository.CreateTestQuest(t, db, 10,
+		repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1})
+	dependOn := func(taskID int, ids ...int) error {
+		_, err := tasks.UpdateTask(ctx, taskID, entity.AnyVersion, &entity.TaskPatch{DependsOn: &ids})
+		return err
+	}
+	if err := dependOn(taskIDs[0], taskIDs[1]); err != nil {
+		t.Fatalf("UpdateTask(1 <- 2): %v", err)
+	}
+	if err := dependOn(taskIDs[1], taskIDs[2]); err != nil {
+		t.Fatalf("UpdateTask(2 <- 3): %v", err)
+	}
+	if _, err := db.Exec(`UPDATE tasks SET deleted_at = NOW() WHERE id = $1`, taskIDs[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dependOn(taskIDs[2], taskIDs[0]); err != nil {
+		t.Fatalf("UpdateTask(3 <- 1) through trashed task: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/service"
+	"sync"
+	"testing"
+)
+
+// Одновременные запросы на выполнение одноразового задания засчитываются один раз,
+// остальные получают отказ «уже выполнено»
+func TestConcurrentTaskCompletion(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	userID := repository.CreateTestUser(t, db)
+	questID, taskIDs := repository.CreateTestQuest(t, db, 100, repository.TestTask{Cost: 10})
+	tasks := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout))
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = tasks.TaskCompletion(context.Background(), &entity.TaskProgress{UserID: userID, TaskID: taskIDs[0]})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, service.ErrAlreadyCompleted):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("succeeded = %d, want 1", succeeded)
+	}
+
+	var completions, questCompletions, balance int
+	if err := db.Get(&completions, `SELECT count(*) FROM tasks_complete WHERE user_id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Get(&questCompletions, `SELECT count(*) FROM quests_complete WHERE user_id = $1 AND quest_id = $2`, userID, questID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Get(&balance, `SELECT balance FROM users WHERE id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+	if completions != 1 || questCompletions != 1 || balance != 110 {
+		t.Fatalf("completions = %d, quest completions = %d, balance = %d, want 1, 1, 110", completions, questCompletions, balance)
+	}
+}
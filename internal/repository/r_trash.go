@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"quest_service/internal/entity"
+	"time"
+)
+
+// TrashRepo — удалённые квесты и задания: просмотр, восстановление и окончательное удаление
+type TrashRepo struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+func NewTrashRepo(db *sqlx.DB, timeout time.Duration) *TrashRepo {
+	return &TrashRepo{db: db, timeout: timeout}
+}
+
+const (
+	lockDeletedQuestQuery = `SELECT version FROM quests WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL`
+	lockDeletedTaskQuery  = `SELECT version FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL`
+)
+
+func (r *TrashRepo) GetDeletedQuests(ctx context.Context, page entity.Page) ([]entity.Quest, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT count(*) FROM quests WHERE deleted_at IS NOT NULL AND purged_at IS NULL`)
+	if err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+
+	quests := []entity.Quest{}
+	questsQuery := `
+		SELECT id, name, cost, created_at, status, sequential, starts_at, ends_at, version, deleted_at
+		FROM quests
+		WHERE deleted_at IS NOT NULL AND purged_at IS NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+	err = r.db.SelectContext(ctx, &quests, questsQuery, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+	for i := range quests {
+		quests[i].Tasks = []entity.Task{}
+	}
+	return quests, total, nil
+}
+
+func (r *TrashRepo) GetDeletedTasks(ctx context.Context, page entity.Page) ([]entity.Task, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT count(*) FROM tasks WHERE deleted_at IS NOT NULL AND purged_at IS NULL`)
+	if err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+
+	tasks := []entity.Task{}
+	tasksQuery := `
+		SELECT id, quest_id, name, is_reusable, cost, position, version, deleted_at
+		FROM tasks
+		WHERE deleted_at IS NOT NULL AND purged_at IS NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+	err = r.db.SelectContext(ctx, &tasks, tasksQuery, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+	return tasks, total, nil
+}
+
+// RestoreQuest возвращает удалённый квест в каталог вместе с заданиями, удалёнными вместе с ним
+func (r *TrashRepo) RestoreQuest(ctx context.Context, questID int) (*entity.Quest, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var quest entity.Quest
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockDeletedQuestQuery, questID, entity.AnyVersion)
+		if err != nil {
+			return err
+		}
+		// Задания, удалённые по отдельности до удаления квеста, остаются в корзине
+		restoreTasksQuery := `
+			UPDATE tasks SET deleted_at = NULL, version = version + 1
+			WHERE quest_id = $1 AND purged_at IS NULL
+			  AND deleted_at = (SELECT deleted_at FROM quests WHERE id = $1)
+		`
+		_, err = tx.ExecContext(ctx, restoreTasksQuery, questID)
+		if err != nil {
+			return err
+		}
+		restoreQuery := `
+			UPDATE quests SET deleted_at = NULL, version = version + 1
+			WHERE id = $1
+			RETURNING id, name, cost, created_at, status, sequential, starts_at, ends_at, version
+		`
+		err = tx.GetContext(ctx, &quest, restoreQuery, questID)
+		if err != nil {
+			return err
+		}
+		quests := []entity.Quest{quest}
+		if err = attachTasks(ctx, tx, quests); err != nil {
+			return err
+		}
+		quest = quests[0]
+		return nil
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &quest, nil
+}
+
+// RestoreTask восстанавливает удалённое задание. Задание удалённого квеста не восстанавливается: ErrParentDeleted
+func (r *TrashRepo) RestoreTask(ctx context.Context, taskID int) (*entity.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var task entity.Task
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var questDeleted bool
+		questQuery := `
+			SELECT q.deleted_at IS NOT NULL
+			FROM tasks t
+			JOIN quests q ON q.id = t.quest_id
+			WHERE t.id = $1 AND t.deleted_at IS NOT NULL AND t.purged_at IS NULL
+			FOR UPDATE OF t
+		`
+		err := tx.GetContext(ctx, &questDeleted, questQuery, taskID)
+		if err != nil {
+			return err
+		}
+		if questDeleted {
+			return ErrParentDeleted
+		}
+		restoreQuery := `
+			UPDATE tasks SET deleted_at = NULL, version = version + 1
+			WHERE id = $1
+			RETURNING id, quest_id, name, is_reusable, cost, position, version
+		`
+		err = tx.GetContext(ctx, &task, restoreQuery, taskID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, bumpQuestVersionQuery, task.QuestID)
+		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &task, nil
+}
+
+// PurgeQuest окончательно удаляет квест из корзины вместе с его заданиями. Если на квест или
+// его задания ссылается история выполнений, они обезличиваются и скрываются из корзины
+func (r *TrashRepo) PurgeQuest(ctx context.Context, questID, version int) (*entity.PurgeResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := &entity.PurgeResult{ID: questID}
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockDeletedQuestQuery, questID, version)
+		if err != nil {
+			return err
+		}
+
+		var referenced bool
+		referencedQuery := `
+			SELECT EXISTS (SELECT 1 FROM quests_complete WHERE quest_id = $1)
+			    OR EXISTS (SELECT 1 FROM tasks_complete tc JOIN tasks t ON t.id = tc.task_id WHERE t.quest_id = $1)
+		`
+		err = tx.GetContext(ctx, &referenced, referencedQuery, questID)
+		if err != nil {
+			return err
+		}
+
+		if referenced {
+			result.Outcome = entity.PurgeAnonymized
+			_, err = tx.ExecContext(ctx, `
+				UPDATE tasks SET name = $2, deleted_at = COALESCE(deleted_at, NOW()), purged_at = NOW(), version = version + 1
+				WHERE quest_id = $1 AND purged_at IS NULL
+			`, questID, entity.AnonymizedName)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `UPDATE quests SET name = $2, purged_at = NOW(), version = version + 1 WHERE id = $1`,
+				questID, entity.AnonymizedName)
+			return err
+		}
+
+		result.Outcome = entity.PurgeDeleted
+		_, err = tx.ExecContext(ctx, `DELETE FROM tasks WHERE quest_id = $1`, questID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM quests WHERE id = $1`, questID)
+		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return result, nil
+}
+
+// PurgeTask окончательно удаляет задание из корзины. Если на него ссылается история
+// выполнений, задание обезличивается и скрывается из корзины
+func (r *TrashRepo) PurgeTask(ctx context.Context, taskID, version int) (*entity.PurgeResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := &entity.PurgeResult{ID: taskID}
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := lockVersion(ctx, tx, lockDeletedTaskQuery, taskID, version)
+		if err != nil {
+			return err
+		}
+
+		var referenced bool
+		err = tx.GetContext(ctx, &referenced, `SELECT EXISTS (SELECT 1 FROM tasks_complete WHERE task_id = $1)`, taskID)
+		if err != nil {
+			return err
+		}
+
+		if referenced {
+			result.Outcome = entity.PurgeAnonymized
+			_, err = tx.ExecContext(ctx, `UPDATE tasks SET name = $2, purged_at = NOW(), version = version + 1 WHERE id = $1`,
+				taskID, entity.AnonymizedName)
+			return err
+		}
+
+		result.Outcome = entity.PurgeDeleted
+		_, err = tx.ExecContext(ctx, `DELETE FROM tasks WHERE id = $1`, taskID)
+		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return result, nil
+}
+
+// GetExpiredQuestIDs возвращает квесты, удалённые раньше before и ещё не очищенные
+func (r *TrashRepo) GetExpiredQuestIDs(ctx context.Context, before time.Time, limit int) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	ids := []int{}
+	query := `
+		SELECT id FROM quests
+		WHERE deleted_at < $1 AND purged_at IS NULL
+		ORDER BY deleted_at
+		LIMIT $2
+	`
+	err := r.db.SelectContext(ctx, &ids, query, before, limit)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return ids, nil
+}
+
+// GetExpiredTaskIDs возвращает задания, удалённые раньше before и ещё не очищенные
+func (r *TrashRepo) GetExpiredTaskIDs(ctx context.Context, before time.Time, limit int) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	ids := []int{}
+	query := `
+		SELECT id FROM tasks
+		WHERE deleted_at < $1 AND purged_at IS NULL
+		ORDER BY deleted_at
+		LIMIT $2
+	`
+	err := r.db.SelectContext(ctx, &ids, query, before, limit)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,222 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/service"
+	"testing"
+	"time"
+)
+
+// Удаление квеста уносит в корзину его задания, восстановление возвращает только их:
+// задание, удалённое раньше квеста, остаётся в корзине
+func TestRestoreQuest(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10,
+		repository.TestTask{Cost: 1}, repository.TestTask{Cost: 2}, repository.TestTask{Cost: 3})
+	if _, err := db.Exec(`UPDATE tasks SET deleted_at = NOW() - INTERVAL '1 hour' WHERE id = $1`, taskIDs[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.NewQuestRepo(db, repository.TestTimeout).DeleteQuest(ctx, questID, entity.AnyVersion); err != nil {
+		t.Fatalf("DeleteQuest: %v", err)
+	}
+	var live int
+	if err := db.Get(&live, `SELECT count(*) FROM tasks WHERE quest_id = $1 AND deleted_at IS NULL`, questID); err != nil {
+		t.Fatal(err)
+	}
+	if live != 0 {
+		t.Fatalf("live tasks after DeleteQuest = %d, want 0", live)
+	}
+	trash := repository.NewTrashRepo(db, repository.TestTimeout)
+
+	quest, err := trash.RestoreQuest(ctx, questID)
+	if err != nil {
+		t.Fatalf("RestoreQuest: %v", err)
+	}
+	if len(quest.Tasks) != 2 {
+		t.Fatalf("tasks = %+v, want tasks %d and %d", quest.Tasks, taskIDs[0], taskIDs[1])
+	}
+	for _, task := range quest.Tasks {
+		if task.ID == taskIDs[2] {
+			t.Fatalf("task %d deleted before the quest was restored", task.ID)
+		}
+	}
+	if _, err = trash.RestoreQuest(ctx, questID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("RestoreQuest(live quest) error = %v, want ErrNotFound", err)
+	}
+}
+
+// Задание удалённого квеста нельзя выполнить, клиент получает ErrDeleted, а не «не найдено»
+func TestTaskCompletionDeletedQuest(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	userID := repository.CreateTestUser(t, db)
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	if err := repository.NewQuestRepo(db, repository.TestTimeout).DeleteQuest(ctx, questID, entity.AnyVersion); err != nil {
+		t.Fatalf("DeleteQuest: %v", err)
+	}
+
+	_, err := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout)).
+		TaskCompletion(ctx, &entity.TaskProgress{UserID: userID, TaskID: taskIDs[0]})
+	if !errors.Is(err, service.ErrDeleted) {
+		t.Fatalf("TaskCompletion error = %v, want ErrDeleted", err)
+	}
+}
+
+// Задание удалённого квеста не восстанавливается, пока не восстановлен квест
+func TestRestoreTaskParentDeleted(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	questID, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	if _, err := db.Exec(`UPDATE tasks SET deleted_at = NOW() WHERE id = $1`, taskIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE quests SET deleted_at = NOW() WHERE id = $1`, questID); err != nil {
+		t.Fatal(err)
+	}
+	trash := repository.NewTrashRepo(db, repository.TestTimeout)
+
+	if _, err := trash.RestoreTask(ctx, taskIDs[0]); !errors.Is(err, repository.ErrParentDeleted) {
+		t.Fatalf("RestoreTask error = %v, want ErrParentDeleted", err)
+	}
+	if _, err := service.NewTrashService(trash).RestoreTask(ctx, taskIDs[0]); !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("TrashService.RestoreTask error = %v, want ErrConflict", err)
+	}
+
+	if _, err := trash.RestoreQuest(ctx, questID); err != nil {
+		t.Fatalf("RestoreQuest: %v", err)
+	}
+	task, err := trash.RestoreTask(ctx, taskIDs[0])
+	if err != nil || task.ID != taskIDs[0] {
+		t.Fatalf("RestoreTask = %+v, %v, want restored task", task, err)
+	}
+}
+
+// Квест без истории выполнений стирается, квест с историей обезличивается
+func TestPurgeQuest(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	trash := repository.NewTrashRepo(db, repository.TestTimeout)
+	userID := repository.CreateTestUser(t, db)
+
+	unused, _ := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	used, usedTasks := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1})
+	_, err := service.NewTaskService(repository.NewTaskRepo(db, repository.TestTimeout)).
+		TaskCompletion(ctx, &entity.TaskProgress{UserID: userID, TaskID: usedTasks[0]})
+	if err != nil {
+		t.Fatalf("TaskCompletion: %v", err)
+	}
+	if _, err = db.Exec(`UPDATE quests SET deleted_at = NOW() WHERE id = ANY(ARRAY[$1, $2]::int[])`, unused, used); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := trash.PurgeQuest(ctx, unused, entity.AnyVersion)
+	if err != nil || result.Outcome != entity.PurgeDeleted {
+		t.Fatalf("PurgeQuest(unused) = %+v, %v, want deleted", result, err)
+	}
+	var count int
+	if err = db.Get(&count, `SELECT count(*) FROM tasks WHERE quest_id = $1`, unused); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("tasks of purged quest = %d, want 0", count)
+	}
+
+	result, err = trash.PurgeQuest(ctx, used, entity.AnyVersion)
+	if err != nil || result.Outcome != entity.PurgeAnonymized {
+		t.Fatalf("PurgeQuest(used) = %+v, %v, want anonymized", result, err)
+	}
+	// История выполнений сохраняется, а обезличенные записи скрыты из корзины
+	err = db.Get(&count, `SELECT count(*) FROM tasks WHERE quest_id = $1 AND name = $2 AND purged_at IS NOT NULL`, used, entity.AnonymizedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("anonymized tasks = %d, want 2", count)
+	}
+	if err = db.Get(&count, `SELECT count(*) FROM tasks_complete WHERE user_id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("completions = %d, want 1", count)
+	}
+	if _, err = trash.PurgeQuest(ctx, used, entity.AnyVersion); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("PurgeQuest(anonymized) error = %v, want ErrNotFound", err)
+	}
+}
+
+// Очистка по сроку хранения обрабатывает больше записей, чем помещается в одну пачку
+func TestPurgeExpiredBatches(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	deletedAt := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	const quests = 150
+	_, err := db.Exec(`
+		INSERT INTO quests (name, cost, deleted_at)
+		SELECT 'expired', 1, $1 FROM generate_series(1, $2)
+	`, deletedAt, quests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, taskIDs := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1}, repository.TestTask{Cost: 1})
+	if _, err = db.Exec(`UPDATE tasks SET deleted_at = $2 WHERE id = $1`, taskIDs[0], deletedAt); err != nil {
+		t.Fatal(err)
+	}
+	// Удалённое после срока задание остаётся в корзине
+	if _, err = db.Exec(`UPDATE tasks SET deleted_at = $2 WHERE id = $1`, taskIDs[1], deletedAt.Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	trash := service.NewTrashService(repository.NewTrashRepo(db, repository.TestTimeout))
+	stats, err := trash.PurgeExpired(ctx, deletedAt.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeExpired: %v", err)
+	}
+	if stats.Quests != quests || stats.Tasks != 1 || stats.Anonymized != 0 {
+		t.Fatalf("stats = %+v, want %d quests and 1 task deleted", stats, quests)
+	}
+	var left int
+	if err = db.Get(&left, `SELECT count(*) FROM tasks WHERE id = $1`, taskIDs[1]); err != nil {
+		t.Fatal(err)
+	}
+	if left != 1 {
+		t.Fatal("task deleted after the cutoff was purged")
+	}
+}
+
+// Задание нельзя добавить в удалённый или несуществующий квест
+func TestCreateTaskParentDeleted(t *testing.T) {
+	db := repository.OpenTestDB(t)
+	ctx := context.Background()
+	tasks := repository.NewTaskRepo(db, repository.TestTimeout)
+	questID, _ := repository.CreateTestQuest(t, db, 10, repository.TestTask{Cost: 1})
+	if err := repository.NewQuestRepo(db, repository.TestTimeout).DeleteQuest(ctx, questID, entity.AnyVersion); err != nil {
+		t.Fatalf("DeleteQuest: %v", err)
+	}
+
+	input := &entity.TaskInput{QuestID: questID, Name: "late", Cost: 1}
+	if _, err := tasks.CreateTask(ctx, input); !errors.Is(err, repository.ErrParentDeleted) {
+		t.Fatalf("CreateTask(deleted quest) error = %v, want ErrParentDeleted", err)
+	}
+	if _, err := service.NewTaskService(tasks).CreateTask(ctx, input); !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("TaskService.CreateTask(deleted quest) error = %v, want ErrConflict", err)
+	}
+	var count int
+	if err := db.Get(&count, `SELECT count(*) FROM tasks WHERE quest_id = $1 AND name = 'late'`, questID); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("tasks created in deleted quest = %d, want 0", count)
+	}
+
+	input.QuestID = -1
+	if _, err := service.NewTaskService(tasks).CreateTask(ctx, input); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("TaskService.CreateTask(missing quest) error = %v, want ErrNotFound", err)
+	}
+}
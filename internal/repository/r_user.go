@@ -1,50 +1,125 @@
 package repository
 
 import (
+	"context"
 	"github.com/jmoiron/sqlx"
 	"quest_service/internal/entity"
+	"time"
 )
 
 type UserRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	timeout time.Duration
 }
 
-func NewUserRepo(db *sqlx.DB) *UserRepo {
-	return &UserRepo{db: db}
+func NewUserRepo(db *sqlx.DB, timeout time.Duration) *UserRepo {
+	return &UserRepo{db: db, timeout: timeout}
 }
 
-func (r *UserRepo) CreateUser(user *entity.UserInput) (int, error) {
+func (r *UserRepo) CreateUser(ctx context.Context, user *entity.UserInput) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var id int
 	query := `INSERT INTO users (username, balance) values ($1, $2) RETURNING id`
 
-	row := r.db.QueryRow(query, user.UserName, 0)
+	row := r.db.QueryRowContext(ctx, query, user.UserName, 0)
 	if err := row.Scan(&id); err != nil {
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
 
 	return id, nil
 }
 
-func (r *UserRepo) GetUserBalance(userID int) (int, error) {
+func (r *UserRepo) GetUserBalance(ctx context.Context, userID int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var balance int
 	query := `SELECT balance FROM users WHERE id = $1`
-	err := r.db.Get(&balance, query, userID)
+	err := r.db.GetContext(ctx, &balance, query, userID)
 	if err != nil {
-		return 0, err
+		return 0, translateError(ctx, err)
 	}
 	return balance, nil
 }
 
-func (r *UserRepo) GetUserTasksHistoryByUserID(userID int) ([]entity.Task, error) {
+// GetUserTasksHistoryByUserID возвращает выполненные пользователем задания, по строке на каждое выполнение.
+// Задания в корзине, в том числе удалённые вместе с квестом, в историю не попадают
+func (r *UserRepo) GetUserTasksHistoryByUserID(ctx context.Context, userID int) ([]entity.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var tasks []entity.Task
 	taskQuery := `
 		SELECT t.id, t.quest_id, t.name, t.is_reusable, t.cost
-    	FROM tasks t
-    	LEFT JOIN tasks_complete tp on t.id = tp.task_id where user_id=$1;
+		FROM tasks t
+		JOIN tasks_complete tc ON tc.task_id = t.id
+		WHERE tc.user_id = $1 AND t.deleted_at IS NULL
+		ORDER BY tc.completed_at, t.id
 	`
-	err := r.db.Select(&tasks, taskQuery, userID)
+	err := r.db.SelectContext(ctx, &tasks, taskQuery, userID)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err)
 	}
 	return tasks, nil
 }
+
+func (r *UserRepo) CreateUserWithPassword(ctx context.Context, user *entity.SignUpInput, passwordHash string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var id int
+	query := `INSERT INTO users (username, balance, password_hash) values ($1, $2, $3) RETURNING id`
+
+	err := r.db.GetContext(ctx, &id, query, user.UserName, 0, passwordHash)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+
+	return id, nil
+}
+
+func (r *UserRepo) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var user entity.User
+	query := `SELECT id, username, balance, role, password_hash FROM users WHERE username = $1`
+	err := r.db.GetContext(ctx, &user, query, username)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &user, nil
+}
+
+func (r *UserRepo) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var user entity.User
+	query := `SELECT id, username, balance, role FROM users WHERE id = $1`
+	err := r.db.GetContext(ctx, &user, query, userID)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &user, nil
+}
+
+func (r *UserRepo) UpdateUserRole(ctx context.Context, userID int, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET role = $1 WHERE id = $2`, role, userID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -1,46 +1,87 @@
 package repository
 
 import (
+	"context"
 	"github.com/jmoiron/sqlx"
+	"quest_service/configs"
 	"quest_service/internal/entity"
+	"time"
 )
 
 type User interface {
-	CreateUser(user *entity.UserInput) (int, error)
-	GetUserBalance(userID int) (int, error)
-	GetUserTasksHistoryByUserID(userID int) ([]entity.Task, error)
+	CreateUser(ctx context.Context, user *entity.UserInput) (int, error)
+	GetUserBalance(ctx context.Context, userID int) (int, error)
+	GetUserTasksHistoryByUserID(ctx context.Context, userID int) ([]entity.Task, error)
+	CreateUserWithPassword(ctx context.Context, user *entity.SignUpInput, passwordHash string) (int, error)
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	GetUserByID(ctx context.Context, userID int) (*entity.User, error)
+	UpdateUserRole(ctx context.Context, userID int, role string) error
 }
 
 type Quest interface {
-	CreateQuest(quest *entity.QuestInput) (int, error)
-	GetQuestsAndTasks() ([]entity.Quest, error)
-	UpdateNameQuest(questID int, quest *entity.QuestInput) error
-	UpdateCostQuest(questID int, quest *entity.QuestInput) error
-	DeleteQuest(questID int) error
+	CreateQuest(ctx context.Context, quest *entity.QuestInput) (int, error)
+	SeedQuests(ctx context.Context, quests []entity.QuestInput, status string) error
+	GetQuests(ctx context.Context, filter *entity.QuestFilter) (*entity.QuestPage, error)
+	GetQuestByID(ctx context.Context, questID int, visibility entity.QuestVisibility) (*entity.Quest, error)
+	GetQuestsProgressByUser(ctx context.Context, userID int, page entity.Page, visibility entity.QuestVisibility) ([]entity.QuestProgress, int, error)
+	GetQuestProgressByUser(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.QuestProgress, error)
+	UpdateQuest(ctx context.Context, questID, version int, patch *entity.QuestPatch) (*entity.Quest, error)
+	ChangeQuestStatus(ctx context.Context, questID, version int, from []string, status string) (*entity.Quest, error)
+	GetPrerequisites(ctx context.Context, questID int, visibility entity.QuestVisibility) (*entity.QuestPrerequisites, error)
+	SetPrerequisites(ctx context.Context, questID, version int, prerequisiteIDs []int) (*entity.QuestPrerequisites, error)
+	ReorderTasks(ctx context.Context, questID, version int, taskIDs []int) (*entity.Quest, error)
+	DeleteQuest(ctx context.Context, questID, version int) error
 }
 
 type Task interface {
-	GetTaskByID(taskID int) (*entity.Task, error)
-	GetCountTaskProgress(task *entity.TaskProgress) (int, error)
-	GetTaskStatusesByQuestAndUser(questID, userID int) ([]entity.TaskStatus, error)
-	TaskCompletion(userID, taskID, taskCost, questID int, isLastTask bool) error
-	CreateTask(task *entity.TaskInput) (int, error)
-	UpdateNameTask(taskID int, name string) error
-	UpdateCostTask(taskID int, cost int) error
-	UpdateIsReusableTask(taskID int, isReusable bool) error
-	DeleteTask(taskID int) error
+	GetTaskByID(ctx context.Context, taskID int, visibility entity.QuestVisibility) (*entity.Task, error)
+	GetTaskStatusesByQuestAndUser(ctx context.Context, questID, userID int) ([]entity.TaskStatus, error)
+	TaskCompletion(ctx context.Context, userID, taskID int, decide func(state *entity.TaskCompletionState) (bool, error)) (*entity.TaskCompletionResult, error)
+	CreateTask(ctx context.Context, task *entity.TaskInput) (int, error)
+	UpdateTask(ctx context.Context, taskID, version int, patch *entity.TaskPatch) (*entity.Task, error)
+	DeleteTask(ctx context.Context, taskID, version int) error
+	GetTaskGraph(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.TaskGraph, error)
+}
+
+type Ledger interface {
+	CreateEntry(ctx context.Context, input *entity.LedgerEntryInput) (*entity.LedgerEntry, error)
+	GetEntriesByUserID(ctx context.Context, userID int, page entity.Page) ([]entity.LedgerEntry, int, error)
+}
+
+type Trash interface {
+	GetDeletedQuests(ctx context.Context, page entity.Page) ([]entity.Quest, int, error)
+	GetDeletedTasks(ctx context.Context, page entity.Page) ([]entity.Task, int, error)
+	RestoreQuest(ctx context.Context, questID int) (*entity.Quest, error)
+	RestoreTask(ctx context.Context, taskID int) (*entity.Task, error)
+	PurgeQuest(ctx context.Context, questID, version int) (*entity.PurgeResult, error)
+	PurgeTask(ctx context.Context, taskID, version int) (*entity.PurgeResult, error)
+	GetExpiredQuestIDs(ctx context.Context, before time.Time, limit int) ([]int, error)
+	GetExpiredTaskIDs(ctx context.Context, before time.Time, limit int) ([]int, error)
+}
+
+type Health interface {
+	Ping(ctx context.Context) error
+	MigrationStatus(ctx context.Context) (*MigrationStatus, error)
+	PoolStats() entity.PoolStats
 }
 
 type Repository struct {
 	User
 	Quest
 	Task
+	Ledger
+	Trash
+	Health
 }
 
-func NewRepository(db *sqlx.DB) *Repository {
+// NewRepository создаёт репозитории. Каждое обращение к БД ограничено cfg.DB.QueryTimeout
+func NewRepository(db *sqlx.DB, migrator *Migrator, cfg configs.Config) *Repository {
 	return &Repository{
-		User:  NewUserRepo(db),
-		Quest: NewQuestRepo(db),
-		Task:  NewTaskRepo(db),
+		User:   NewUserRepo(db, cfg.DB.QueryTimeout),
+		Quest:  NewQuestRepo(db, cfg.DB.QueryTimeout),
+		Task:   NewTaskRepo(db, cfg.DB.QueryTimeout),
+		Ledger: NewLedgerRepo(db, cfg.DB.QueryTimeout),
+		Trash:  NewTrashRepo(db, cfg.DB.QueryTimeout),
+		Health: NewHealthRepo(db, migrator, cfg.DB.QueryTimeout),
 	}
 }
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"log/slog"
+	"time"
+)
+
+// Коды ошибок PostgreSQL, после которых транзакцию можно безопасно повторить
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+const (
+	txMaxAttempts = 5
+	txRetryDelay  = 10 * time.Millisecond
+)
+
+// runInTx выполняет fn в serializable-транзакции.
+// При конфликте сериализации или взаимной блокировке транзакция повторяется целиком,
+// пока не истёк ctx. Если конфликт не разрешился за txMaxAttempts попыток, возвращает ErrTxConflict
+func runInTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= txMaxAttempts; attempt++ {
+		err = execTx(ctx, db, fn)
+		if !isRetryable(err) {
+			return err
+		}
+		slog.WarnContext(ctx, "Повтор транзакции после конфликта", "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrTxConflict, err)
+}
+
+func execTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == codeSerializationFailure || pqErr.Code == codeDeadlockDetected
+	}
+	return false
+}
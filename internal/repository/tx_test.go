@@ -0,0 +1,69 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"testing"
+)
+
+// Конфликт сериализации повторяет транзакцию целиком на новом снимке данных
+func TestRunInTxRetriesSerializationFailure(t *testing.T) {
+	db := OpenTestDB(t)
+	userID := CreateTestUser(t, db)
+
+	attempts := 0
+	err := runInTx(context.Background(), db, func(tx *sqlx.Tx) error {
+		attempts++
+		var balance int
+		if err := tx.Get(&balance, `SELECT balance FROM users WHERE id = $1`, userID); err != nil {
+			return err
+		}
+		// Строку меняет другая транзакция после снимка первой попытки
+		if attempts == 1 {
+			if _, err := db.Exec(`UPDATE users SET balance = balance + 1 WHERE id = $1`, userID); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(`UPDATE users SET balance = $2 WHERE id = $1`, userID, balance+10)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("runInTx: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want 2", attempts)
+	}
+
+	var balance int
+	if err := db.Get(&balance, `SELECT balance FROM users WHERE id = $1`, userID); err != nil {
+		t.Fatal(err)
+	}
+	if balance != 11 {
+		t.Fatalf("balance = %d, want 11", balance)
+	}
+}
+
+// Конфликт, не разрешившийся за txMaxAttempts попыток, возвращается как ErrTxConflict
+func TestRunInTxGivesUpAfterConflicts(t *testing.T) {
+	db := OpenTestDB(t)
+
+	attempts := 0
+	err := runInTx(context.Background(), db, func(tx *sqlx.Tx) error {
+		attempts++
+		return &pq.Error{Code: codeSerializationFailure}
+	})
+	if !errors.Is(err, ErrTxConflict) {
+		t.Fatalf("runInTx error = %v, want ErrTxConflict", err)
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != codeSerializationFailure {
+		t.Fatalf("runInTx error = %v, want wrapped serialization failure", err)
+	}
+	if attempts != txMaxAttempts {
+		t.Fatalf("attempts = %d, want %d", attempts, txMaxAttempts)
+	}
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"quest_service/internal/entity"
+)
+
+// lockVersion блокирует строку до конца транзакции и сверяет её версию с ожидаемой.
+// query выбирает version по id и отсекает удалённые записи
+func lockVersion(ctx context.Context, tx *sqlx.Tx, query string, id, version int) error {
+	var current int
+	err := tx.GetContext(ctx, &current, query+" FOR UPDATE", id)
+	if err != nil {
+		return err
+	}
+	if version != entity.AnyVersion && current != version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+const (
+	lockQuestQuery = `SELECT version FROM quests WHERE id = $1 AND deleted_at IS NULL`
+	lockTaskQuery  = `
+		SELECT t.version FROM tasks t
+		JOIN quests q ON q.id = t.quest_id
+		WHERE t.id = $1 AND t.deleted_at IS NULL AND q.deleted_at IS NULL
+	`
+	// Задания входят в представление квеста, поэтому их изменение меняет и версию квеста
+	bumpQuestVersionQuery = `UPDATE quests SET version = version + 1 WHERE id = $1`
+)
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"quest_service/configs"
+)
+
+type Server struct {
+	httpServer *http.Server
+	tls        configs.TLSConfig
+}
+
+// New создаёт HTTP-сервер с таймаутами чтения, записи и простоя соединений
+func New(cfg configs.Config, handler http.Handler) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              ":" + cfg.HTTP.Port,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.HTTP.ReadTimeout,
+			ReadTimeout:       cfg.HTTP.ReadTimeout,
+			WriteTimeout:      cfg.HTTP.WriteTimeout,
+			IdleTimeout:       cfg.HTTP.IdleTimeout,
+			MaxHeaderBytes:    1 << 20,
+		},
+		tls: cfg.HTTP.TLS,
+	}
+}
+
+// Run принимает соединения до вызова Shutdown или Close. После них возвращает http.ErrServerClosed.
+// Если задан сертификат, соединения принимаются по TLS
+func (s *Server) Run() error {
+	if s.tls.Enabled() {
+		return s.httpServer.ListenAndServeTLS(s.tls.CertFile, s.tls.KeyFile)
+	}
+	return s.httpServer.ListenAndServe()
+}
+
+// Shutdown перестаёт принимать соединения и ждёт завершения начатых запросов, но не дольше ctx
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// Close немедленно закрывает все соединения. Контексты незавершённых запросов отменяются
+func (s *Server) Close() error {
+	return s.httpServer.Close()
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"quest_service/configs"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// freePort возвращает свободный TCP-порт
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// startServer запускает сервер и ждёт, пока он начнёт принимать соединения
+func startServer(t *testing.T, handler http.Handler) (*Server, string, chan error) {
+	t.Helper()
+	port := freePort(t)
+	srv := New(configs.Config{HTTP: configs.HTTPConfig{
+		Port:         port,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+		IdleTimeout:  time.Second,
+	}}, handler)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- srv.Run()
+	}()
+
+	url := "http://127.0.0.1:" + port
+	for i := 0; i < 100; i++ {
+		conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+		if err == nil {
+			conn.Close()
+			return srv, url, runErr
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("server did not start")
+	return nil, "", nil
+}
+
+// Shutdown дожидается начатого запроса, после чего Run возвращает http.ErrServerClosed
+func TestShutdownWaitsForRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv, url, runErr := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	respErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		respErr <- err
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- srv.Shutdown(context.Background())
+	}()
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before the request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-respErr; err != nil {
+		t.Fatalf("in-flight request failed: %v", err)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-runErr; !errors.Is(err, http.ErrServerClosed) {
+		t.Fatalf("Run() error = %v, want http.ErrServerClosed", err)
+	}
+}
+
+// По истечении срока Shutdown возвращает ошибку, а Close обрывает запрос и отменяет его контекст
+func TestShutdownTimeoutThenClose(t *testing.T) {
+	canceled := make(chan struct{})
+	started := make(chan struct{})
+	srv, url, runErr := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(canceled)
+	}))
+
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+	if err := srv.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("request context was not canceled by Close")
+	}
+	if err := <-runErr; !errors.Is(err, http.ErrServerClosed) {
+		t.Fatalf("Run() error = %v, want http.ErrServerClosed", err)
+	}
+}
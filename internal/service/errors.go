@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"quest_service/internal/repository"
+)
+
+// Виды ошибок бизнес-логики. Проверяются через errors.Is
+var (
+	ErrNotFound           = errors.New("не найдено")
+	ErrAlreadyCompleted   = errors.New("уже выполнено")
+	ErrConflict           = errors.New("конфликт")
+	ErrValidation         = errors.New("ошибка валидации")
+	ErrUnauthorized       = errors.New("не аутентифицирован")
+	ErrForbidden          = errors.New("доступ запрещён")
+	ErrPreconditionFailed = errors.New("версия устарела")
+	ErrDeleted            = errors.New("удалено")
+	ErrOutsideWindow      = errors.New("вне окна доступности")
+	ErrQuestInactive      = errors.New("квест не принимает выполнения")
+	ErrQuestLocked        = errors.New("квест заблокирован")
+	ErrOutOfOrder         = errors.New("нарушен порядок заданий")
+	// ErrRetryable — транзакция не прошла из-за параллельных запросов, запрос можно повторить
+	ErrRetryable = repository.ErrTxConflict
+)
+
+// Error — ошибка бизнес-логики с сообщением для клиента
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func wrapError(kind error, message string, err error) error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// translateNotFound заменяет отсутствие записи в репозитории на ErrNotFound с сообщением
+func translateNotFound(err error, message string) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return wrapError(ErrNotFound, message, err)
+	}
+	return err
+}
+
+// translateVersionMismatch заменяет несовпадение версии в репозитории на ErrPreconditionFailed
+func translateVersionMismatch(err error, message string) error {
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		return wrapError(ErrPreconditionFailed, message, err)
+	}
+	return err
+}
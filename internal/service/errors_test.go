@@ -0,0 +1,25 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"quest_service/internal/repository"
+	"testing"
+)
+
+func TestTranslateNotFound(t *testing.T) {
+	err := translateNotFound(fmt.Errorf("get: %w", repository.ErrNotFound), "Квест не найден")
+	var serviceErr *Error
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &serviceErr) || serviceErr.Message != "Квест не найден" {
+		t.Fatalf("translateNotFound() = %v, want ErrNotFound with message", err)
+	}
+	// Исходная ошибка остаётся доступной через Unwrap
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("translateNotFound() = %v, want wrapped repository.ErrNotFound", err)
+	}
+
+	other := errors.New("boom")
+	if got := translateNotFound(other, "Квест не найден"); got != other {
+		t.Fatalf("translateNotFound(other) = %v, want original error", got)
+	}
+}
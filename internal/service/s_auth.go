@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"quest_service/configs"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/tracing"
+	"strconv"
+	"time"
+)
+
+// Типы токенов: access-токен открывает доступ к API, refresh-токен — только к выпуску новой пары
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	TokenType string `json:"typ"`
+	Role      string `json:"role,omitempty"`
+}
+
+type AuthService struct {
+	userRepo        repository.User
+	signingKey      []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+func NewAuthService(userRepo repository.User, cfg configs.Config) *AuthService {
+	return &AuthService{
+		userRepo:        userRepo,
+		signingKey:      []byte(cfg.Auth.JWTSecret),
+		accessTokenTTL:  cfg.Auth.AccessTokenTTL,
+		refreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+	}
+}
+
+func (s *AuthService) SignUp(ctx context.Context, input *entity.SignUpInput) (int, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.SignUp")
+	defer span.End()
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	userID, err := s.userRepo.CreateUserWithPassword(ctx, input, string(passwordHash))
+	if repository.IsConstraint(err, "users_username_key") {
+		return 0, wrapError(ErrConflict, "Пользователь с таким именем уже существует", err)
+	}
+	return userID, err
+}
+
+func (s *AuthService) SignIn(ctx context.Context, input *entity.SignInInput) (*entity.Tokens, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.SignIn")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByUsername(ctx, input.UserName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, newError(ErrUnauthorized, "Неверное имя пользователя или пароль")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// У пользователей, созданных без регистрации, пароля нет
+	if user.PasswordHash == nil {
+		return nil, newError(ErrUnauthorized, "Неверное имя пользователя или пароль")
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(input.Password))
+	if err != nil {
+		return nil, newError(ErrUnauthorized, "Неверное имя пользователя или пароль")
+	}
+
+	return s.generateTokens(user)
+}
+
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*entity.Tokens, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Refresh")
+	defer span.End()
+
+	identity, err := s.parseToken(refreshToken, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	// Роль перечитывается из БД, чтобы её изменение вступило в силу с новой парой токенов
+	user, err := s.tokenUser(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.generateTokens(user)
+}
+
+// ParseToken проверяет access-токен и возвращает пользователя с его текущей ролью.
+// Роль берётся из БД, а не из токена: смена роли и удаление пользователя действуют
+// сразу, а не после истечения выданного access-токена
+func (s *AuthService) ParseToken(ctx context.Context, accessToken string) (*entity.Identity, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ParseToken")
+	defer span.End()
+
+	identity, err := s.parseToken(accessToken, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.tokenUser(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	identity.Role = user.Role
+	return identity, nil
+}
+
+// tokenUser возвращает владельца токена. Токен удалённого пользователя недействителен
+func (s *AuthService) tokenUser(ctx context.Context, userID int) (*entity.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, newError(ErrUnauthorized, "Недействительный токен")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AuthService) generateTokens(user *entity.User) (*entity.Tokens, error) {
+	accessToken, err := s.signToken(user, tokenTypeAccess, s.accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.signToken(user, tokenTypeRefresh, s.refreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *AuthService) signToken(user *entity.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		TokenType: tokenType,
+		Role:      user.Role,
+	})
+	return token.SignedString(s.signingKey)
+}
+
+func (s *AuthService) parseToken(tokenString, tokenType string) (*entity.Identity, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return s.signingKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, wrapError(ErrUnauthorized, "Недействительный токен", err)
+	}
+	if claims.TokenType != tokenType {
+		return nil, newError(ErrUnauthorized, "Недействительный токен")
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, wrapError(ErrUnauthorized, "Недействительный токен", err)
+	}
+	return &entity.Identity{UserID: userID, Role: claims.Role}, nil
+}
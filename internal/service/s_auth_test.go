@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"quest_service/configs"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSigningKey = "test-secret"
+
+// authUserRepo возвращает пользователя users[id] или ErrNotFound
+type authUserRepo struct {
+	repository.User
+	users map[int]*entity.User
+}
+
+func (r *authUserRepo) GetUserByID(_ context.Context, userID int) (*entity.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func newTestAuthService(users ...*entity.User) *AuthService {
+	repo := &authUserRepo{users: map[int]*entity.User{}}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return NewAuthService(repo, configs.Config{Auth: configs.AuthConfig{
+		JWTSecret:       testSigningKey,
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	}})
+}
+
+// signTestToken подписывает произвольные claims заданным методом
+func signTestToken(t *testing.T, method jwt.SigningMethod, key any, claims jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return token
+}
+
+func testClaims(subject, tokenType string, expiresAt time.Time) *tokenClaims {
+	return &tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(expiresAt.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		TokenType: tokenType,
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	s := newTestAuthService()
+	tokens, err := s.generateTokens(&entity.User{ID: 42, Role: entity.RoleEditor})
+	if err != nil {
+		t.Fatalf("generateTokens: %v", err)
+	}
+	future := time.Now().Add(time.Hour)
+	hs256 := func(claims jwt.Claims) string {
+		return signTestToken(t, jwt.SigningMethodHS256, []byte(testSigningKey), claims)
+	}
+	// Меняем символ в середине подписи: последний символ base64 может содержать только биты выравнивания
+	sig := strings.LastIndex(tokens.AccessToken, ".") + 10
+	flipped := byte('A')
+	if tokens.AccessToken[sig] == 'A' {
+		flipped = 'B'
+	}
+	tampered := tokens.AccessToken[:sig] + string(flipped) + tokens.AccessToken[sig+1:]
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType string
+		wantID    int
+	}{
+		{"access-токен", tokens.AccessToken, tokenTypeAccess, 42},
+		{"refresh-токен", tokens.RefreshToken, tokenTypeRefresh, 42},
+		{"refresh вместо access", tokens.RefreshToken, tokenTypeAccess, 0},
+		{"access вместо refresh", tokens.AccessToken, tokenTypeRefresh, 0},
+		{"алгоритм none", signTestToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, testClaims("42", tokenTypeAccess, future)), tokenTypeAccess, 0},
+		{"алгоритм HS512", signTestToken(t, jwt.SigningMethodHS512, []byte(testSigningKey), testClaims("42", tokenTypeAccess, future)), tokenTypeAccess, 0},
+		{"другой ключ", signTestToken(t, jwt.SigningMethodHS256, []byte("other"), testClaims("42", tokenTypeAccess, future)), tokenTypeAccess, 0},
+		{"истёкший токен", hs256(testClaims("42", tokenTypeAccess, time.Now().Add(-time.Minute))), tokenTypeAccess, 0},
+		{"нечисловой sub", hs256(testClaims("admin", tokenTypeAccess, future)), tokenTypeAccess, 0},
+		{"пустой sub", hs256(testClaims("", tokenTypeAccess, future)), tokenTypeAccess, 0},
+		{"без типа", hs256(testClaims("42", "", future)), tokenTypeAccess, 0},
+		{"подделанная подпись", tampered, tokenTypeAccess, 0},
+		{"не JWT", "garbage", tokenTypeAccess, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := s.parseToken(tt.token, tt.tokenType)
+			if tt.wantID != 0 {
+				if err != nil || identity.UserID != tt.wantID || identity.Role != entity.RoleEditor {
+					t.Fatalf("parseToken() = %+v, %v, want user %d with role editor", identity, err, tt.wantID)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnauthorized) {
+				t.Fatalf("parseToken() = %+v, %v, want ErrUnauthorized", identity, err)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	user := &entity.User{ID: 7, Role: entity.RolePlayer}
+	s := newTestAuthService(user)
+	tokens, err := s.generateTokens(user)
+	if err != nil {
+		t.Fatalf("generateTokens: %v", err)
+	}
+	if _, err := s.Refresh(context.Background(), tokens.AccessToken); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Refresh(access) error = %v, want ErrUnauthorized", err)
+	}
+
+	// Новая пара токенов получает роль из БД
+	user.Role = entity.RoleAdmin
+	refreshed, err := s.Refresh(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh(refresh): %v", err)
+	}
+	identity, err := s.parseToken(refreshed.AccessToken, tokenTypeAccess)
+	if err != nil || identity.UserID != 7 || identity.Role != entity.RoleAdmin {
+		t.Fatalf("parseToken(refreshed) = %+v, %v, want user 7 with role admin", identity, err)
+	}
+
+	// Удалённый пользователь не получает новых токенов
+	delete(s.userRepo.(*authUserRepo).users, 7)
+	if _, err := s.Refresh(context.Background(), tokens.RefreshToken); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Refresh(deleted user) error = %v, want ErrUnauthorized", err)
+	}
+}
+
+// Смена роли и удаление пользователя действуют на уже выданный access-токен
+func TestParseTokenCurrentRole(t *testing.T) {
+	user := &entity.User{ID: 7, Role: entity.RoleAdmin}
+	s := newTestAuthService(user)
+	tokens, err := s.generateTokens(user)
+	if err != nil {
+		t.Fatalf("generateTokens: %v", err)
+	}
+
+	user.Role = entity.RolePlayer
+	identity, err := s.ParseToken(context.Background(), tokens.AccessToken)
+	if err != nil || identity.UserID != 7 || identity.Role != entity.RolePlayer {
+		t.Fatalf("ParseToken(demoted) = %+v, %v, want user 7 with role player", identity, err)
+	}
+
+	delete(s.userRepo.(*authUserRepo).users, 7)
+	if _, err = s.ParseToken(context.Background(), tokens.AccessToken); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("ParseToken(deleted user) error = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/tracing"
+	"sync/atomic"
+)
+
+type HealthService struct {
+	healthRepo repository.Health
+	draining   atomic.Bool
+}
+
+func NewHealthService(healthRepo repository.Health) *HealthService {
+	return &HealthService{healthRepo: healthRepo}
+}
+
+// Ready проверяет доступность БД и актуальность схемы.
+// Во время плавной остановки сервис не готов, чтобы балансировщик перестал направлять трафик.
+func (s *HealthService) Ready(ctx context.Context) *entity.Readiness {
+	ctx, span := tracing.Start(ctx, "HealthService.Ready")
+	defer span.End()
+
+	readiness := &entity.Readiness{
+		Draining: s.draining.Load(),
+		Database: "ok",
+		Pool:     s.healthRepo.PoolStats(),
+	}
+
+	if err := s.healthRepo.Ping(ctx); err != nil {
+		slog.WarnContext(ctx, "Проверка готовности: БД недоступна", "error", err)
+		readiness.Database = "unavailable"
+		return readiness
+	}
+
+	status, err := s.healthRepo.MigrationStatus(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Проверка готовности: не удалось получить версию схемы", "error", err)
+		readiness.Database = "unavailable"
+		return readiness
+	}
+	readiness.Migrations = entity.MigrationsState{
+		Version: status.Version,
+		Latest:  status.Latest,
+		Dirty:   status.Dirty,
+		Pending: len(status.Pending),
+	}
+
+	// Схема новее сборки допустима: так старые экземпляры работают во время выкатки
+	migrationsCurrent := !status.Dirty && len(status.Pending) == 0
+	readiness.Ready = !readiness.Draining && migrationsCurrent
+	return readiness
+}
+
+// StartDraining переводит сервис в состояние остановки
+func (s *HealthService) StartDraining() {
+	s.draining.Store(true)
+}
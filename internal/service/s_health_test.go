@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"testing"
+)
+
+type fakeHealthRepo struct {
+	pingErr   error
+	status    *repository.MigrationStatus
+	statusErr error
+}
+
+func (r *fakeHealthRepo) Ping(context.Context) error {
+	return r.pingErr
+}
+
+func (r *fakeHealthRepo) MigrationStatus(context.Context) (*repository.MigrationStatus, error) {
+	return r.status, r.statusErr
+}
+
+func (r *fakeHealthRepo) PoolStats() entity.PoolStats {
+	return entity.PoolStats{MaxOpen: 10}
+}
+
+func TestHealthReady(t *testing.T) {
+	current := &repository.MigrationStatus{Version: 5, Latest: 5}
+	tests := []struct {
+		name     string
+		repo     *fakeHealthRepo
+		draining bool
+		ready    bool
+		database string
+	}{
+		{"готов", &fakeHealthRepo{status: current}, false, true, "ok"},
+		{"БД недоступна", &fakeHealthRepo{pingErr: errors.New("down")}, false, false, "unavailable"},
+		{"нет версии схемы", &fakeHealthRepo{statusErr: errors.New("boom")}, false, false, "unavailable"},
+		{"есть неприменённые миграции", &fakeHealthRepo{status: &repository.MigrationStatus{
+			Version: 4, Latest: 5, Pending: []repository.Migration{{Version: 5}},
+		}}, false, false, "ok"},
+		{"схема dirty", &fakeHealthRepo{status: &repository.MigrationStatus{Version: 5, Latest: 5, Dirty: true}}, false, false, "ok"},
+		// Во время выкатки старые экземпляры работают со схемой новее своей сборки
+		{"схема новее сборки", &fakeHealthRepo{status: &repository.MigrationStatus{Version: 6, Latest: 5}}, false, true, "ok"},
+		{"остановка", &fakeHealthRepo{status: current}, true, false, "ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewHealthService(tt.repo)
+			if tt.draining {
+				s.StartDraining()
+			}
+			readiness := s.Ready(context.Background())
+			if readiness.Ready != tt.ready || readiness.Database != tt.database || readiness.Draining != tt.draining {
+				t.Fatalf("Ready() = %+v, want ready=%v database=%q draining=%v", readiness, tt.ready, tt.database, tt.draining)
+			}
+			if readiness.Pool.MaxOpen != 10 {
+				t.Fatalf("Pool = %+v, want pool stats", readiness.Pool)
+			}
+		})
+	}
+}
@@ -1,51 +1,191 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"math/rand"
 	"quest_service/internal/entity"
 	"quest_service/internal/repository"
+	"quest_service/internal/tracing"
 	"strconv"
 )
 
 type QuestService struct {
 	questRepo repository.Quest
+	taskRepo  repository.Task
 }
 
-func NewQuestService(questRepo repository.Quest) *QuestService {
-	return &QuestService{questRepo: questRepo}
+func NewQuestService(questRepo repository.Quest, taskRepo repository.Task) *QuestService {
+	return &QuestService{questRepo: questRepo, taskRepo: taskRepo}
 }
 
-func (s *QuestService) CreateQuest(quest *entity.QuestInput) (int, error) {
-	return s.questRepo.CreateQuest(quest)
+func (s *QuestService) CreateQuest(ctx context.Context, quest *entity.QuestInput) (int, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.CreateQuest")
+	defer span.End()
+
+	questID, err := s.questRepo.CreateQuest(ctx, quest)
+	if repository.IsConstraint(err, "quests_window_check") {
+		return 0, wrapError(ErrValidation, "Окончание квеста должно быть позже начала", err)
+	}
+	return questID, err
 }
 
-func (s *QuestService) GetQuestsAndTasks() ([]entity.Quest, error) {
-	return s.questRepo.GetQuestsAndTasks()
+func (s *QuestService) GetQuests(ctx context.Context, filter *entity.QuestFilter) (*entity.QuestPage, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.GetQuests")
+	defer span.End()
+
+	page, err := s.questRepo.GetQuests(ctx, filter)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return nil, wrapError(ErrValidation, "Неверный курсор", err)
+	}
+	return page, err
 }
 
-func (s *QuestService) UpdateQuest(questID int, quest *entity.QuestInput) error {
+// GetQuest возвращает квест, если он виден с правами visibility
+func (s *QuestService) GetQuest(ctx context.Context, questID int, visibility entity.QuestVisibility) (*entity.Quest, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.GetQuest", tracing.QuestID(questID))
+	defer span.End()
 
-	if quest.Name != "" {
-		err := s.questRepo.UpdateNameQuest(questID, quest)
-		if err != nil {
-			return err
-		}
+	quest, err := s.questRepo.GetQuestByID(ctx, questID, visibility)
+	if err != nil {
+		return nil, translateNotFound(err, "Квест не найден")
 	}
+	return quest, nil
+}
 
-	err := s.questRepo.UpdateCostQuest(questID, quest)
+// GetQuestsProgress возвращает прогресс пользователя по квестам, видимым с правами visibility
+func (s *QuestService) GetQuestsProgress(ctx context.Context, userID int, page entity.Page, visibility entity.QuestVisibility) ([]entity.QuestProgress, int, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.GetQuestsProgress", tracing.UserID(userID))
+	defer span.End()
+
+	progress, total, err := s.questRepo.GetQuestsProgressByUser(ctx, userID, page, visibility)
 	if err != nil {
-		return err
+		return nil, 0, err
+	}
+	for i := range progress {
+		progress[i].CalculatePercent()
 	}
+	return progress, total, nil
+}
 
-	return nil
+// GetQuestProgress возвращает прогресс по квесту с разбивкой по заданиям, если квест виден с правами visibility
+func (s *QuestService) GetQuestProgress(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.QuestProgress, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.GetQuestProgress", tracing.UserID(userID), tracing.QuestID(questID))
+	defer span.End()
+
+	progress, err := s.questRepo.GetQuestProgressByUser(ctx, userID, questID, visibility)
+	if err != nil {
+		return nil, translateNotFound(err, "Квест не найден")
+	}
+	progress.CalculatePercent()
+
+	progress.Tasks, err = s.taskRepo.GetTaskStatusesByQuestAndUser(ctx, questID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return progress, nil
 }
 
-func (s *QuestService) DeleteQuest(questID int) error {
-	return s.questRepo.DeleteQuest(questID)
+// UpdateQuest обновляет квест, если его версия совпадает с version
+func (s *QuestService) UpdateQuest(ctx context.Context, questID, version int, patch *entity.QuestPatch) (*entity.Quest, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.UpdateQuest", tracing.QuestID(questID))
+	defer span.End()
+
+	quest, err := s.questRepo.UpdateQuest(ctx, questID, version, patch)
+	// Новая граница окна может противоречить сохранённой
+	if repository.IsConstraint(err, "quests_window_check") {
+		return nil, wrapError(ErrValidation, "Окончание квеста должно быть позже начала", err)
+	}
+	if err != nil {
+		err = translateVersionMismatch(err, "Квест изменён другим запросом, получите актуальную версию")
+		return nil, translateNotFound(err, "Квест не найден")
+	}
+	return quest, nil
 }
 
-func (s *QuestService) CreateTestQuestData() error {
+// ChangeQuestStatus переводит квест в статус status, если его версия совпадает с version
+func (s *QuestService) ChangeQuestStatus(ctx context.Context, questID, version int, status string) (*entity.Quest, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.ChangeQuestStatus", tracing.QuestID(questID))
+	defer span.End()
+
+	quest, err := s.questRepo.ChangeQuestStatus(ctx, questID, version, entity.QuestStatusesBefore(status), status)
+	if errors.Is(err, repository.ErrInvalidTransition) {
+		return nil, wrapError(ErrConflict, "Квест нельзя перевести в статус "+status+" из текущего", err)
+	}
+	if err != nil {
+		err = translateVersionMismatch(err, "Квест изменён другим запросом, получите актуальную версию")
+		return nil, translateNotFound(err, "Квест не найден")
+	}
+	return quest, nil
+}
+
+// GetPrerequisites возвращает квесты-условия квеста, если он виден с правами visibility
+func (s *QuestService) GetPrerequisites(ctx context.Context, questID int, visibility entity.QuestVisibility) (*entity.QuestPrerequisites, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.GetPrerequisites", tracing.QuestID(questID))
+	defer span.End()
+
+	prerequisites, err := s.questRepo.GetPrerequisites(ctx, questID, visibility)
+	if err != nil {
+		return nil, translateNotFound(err, "Квест не найден")
+	}
+	return prerequisites, nil
+}
+
+// SetPrerequisites заменяет квесты-условия квеста, если его версия совпадает с version
+func (s *QuestService) SetPrerequisites(ctx context.Context, questID, version int, input *entity.QuestPrerequisitesInput) (*entity.QuestPrerequisites, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.SetPrerequisites", tracing.QuestID(questID))
+	defer span.End()
+
+	prerequisites, err := s.questRepo.SetPrerequisites(ctx, questID, version, input.PrerequisiteIDs)
+	switch {
+	case errors.Is(err, repository.ErrCycle):
+		return nil, wrapError(ErrValidation, "Квесты-условия замыкают цепочку в цикл", err)
+	case errors.Is(err, repository.ErrReferenceMissing):
+		return nil, wrapError(ErrValidation, "Квест-условие не найден", err)
+	case err != nil:
+		err = translateVersionMismatch(err, "Квест изменён другим запросом, получите актуальную версию")
+		return nil, translateNotFound(err, "Квест не найден")
+	}
+	return prerequisites, nil
+}
+
+// ReorderTasks задаёт порядок заданий квеста, если его версия совпадает с version
+func (s *QuestService) ReorderTasks(ctx context.Context, questID, version int, input *entity.TaskOrderInput) (*entity.Quest, error) {
+	ctx, span := tracing.Start(ctx, "QuestService.ReorderTasks", tracing.QuestID(questID))
+	defer span.End()
+
+	quest, err := s.questRepo.ReorderTasks(ctx, questID, version, input.TaskIDs)
+	if errors.Is(err, repository.ErrSetMismatch) {
+		return nil, wrapError(ErrValidation, "Список должен содержать каждое задание квеста ровно один раз", err)
+	}
+	if err != nil {
+		err = translateVersionMismatch(err, "Квест изменён другим запросом, получите актуальную версию")
+		return nil, translateNotFound(err, "Квест не найден")
+	}
+	return quest, nil
+}
+
+// DeleteQuest удаляет квест, если его версия совпадает с version
+func (s *QuestService) DeleteQuest(ctx context.Context, questID, version int) error {
+	ctx, span := tracing.Start(ctx, "QuestService.DeleteQuest", tracing.QuestID(questID))
+	defer span.End()
+
+	err := s.questRepo.DeleteQuest(ctx, questID, version)
+	if err != nil {
+		err = translateVersionMismatch(err, "Квест изменён другим запросом, получите актуальную версию")
+		return translateNotFound(err, "Квест не найден")
+	}
+	return nil
+}
+
+// CreateTestQuestData создаёт 1000 опубликованных квестов одной транзакцией: при ошибке или
+// таймауте не остаётся частично созданных данных
+func (s *QuestService) CreateTestQuestData(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "QuestService.CreateTestQuestData")
+	defer span.End()
+
 	//	Создаём 1000 квестов
+	quests := make([]entity.QuestInput, 0, 1000)
 	for i := 0; i < 1000; i++ {
 		//	 Создаём рандомное количество заданий
 		var tasks []entity.TaskInput
@@ -57,14 +197,12 @@ func (s *QuestService) CreateTestQuestData() error {
 			})
 		}
 
-		_, err := s.questRepo.CreateQuest(&entity.QuestInput{
+		quests = append(quests, entity.QuestInput{
 			Name:  "quest" + "_" + strconv.Itoa(i) + "_" + strconv.Itoa(len(tasks)),
 			Cost:  rand.Intn(1000),
 			Tasks: tasks,
 		})
-		if err != nil {
-			return err
-		}
 	}
-	return nil
+	//	Тестовые квесты сразу доступны игрокам
+	return s.questRepo.SeedQuests(ctx, quests, entity.QuestStatusPublished)
 }
@@ -1,9 +1,16 @@
 package service
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"log/slog"
 	"quest_service/internal/entity"
+	"quest_service/internal/metrics"
 	"quest_service/internal/repository"
+	"quest_service/internal/tracing"
+	"time"
 )
 
 type TaskService struct {
@@ -14,66 +21,187 @@ func NewTaskService(taskRepo repository.Task) *TaskService {
 	return &TaskService{taskRepo: taskRepo}
 }
 
-func (s *TaskService) TaskCompletion(taskProgress *entity.TaskProgress) error {
-	// Проверка на существование задания
-	taskInfo, err := s.taskRepo.GetTaskByID(taskProgress.TaskID)
-	if err != nil {
-		return err
-	}
-	if taskInfo.ID == 0 {
-		return fmt.Errorf("Задание не найдено")
+func (s *TaskService) TaskCompletion(ctx context.Context, taskProgress *entity.TaskProgress) (*entity.TaskCompletionResult, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.TaskCompletion", tracing.UserID(taskProgress.UserID), tracing.TaskID(taskProgress.TaskID))
+	defer span.End()
+
+	start := time.Now()
+	result, err := s.completeTask(ctx, taskProgress)
+	outcome := completionResult(err)
+	metrics.ObserveTaskCompletion(outcome, time.Since(start))
+	span.SetAttributes(attribute.String("result", outcome))
+	if outcome == metrics.ResultError {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "Не удалось выполнить задание",
+			"user_id", taskProgress.UserID, "task_id", taskProgress.TaskID, "error", err)
 	}
-	//	Есть ли уже записи о выполнении задания
-	countTaskProgress, err := s.taskRepo.GetCountTaskProgress(taskProgress)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if countTaskProgress > 0 && !taskInfo.IsReusable {
-		return fmt.Errorf("Вы уже выполнили это задание")
+	span.SetAttributes(tracing.QuestID(result.QuestID))
+	metrics.RecordTaskCompleted(result)
+	return result, nil
+}
+
+func (s *TaskService) completeTask(ctx context.Context, taskProgress *entity.TaskProgress) (*entity.TaskCompletionResult, error) {
+	// Все проверки выполняются внутри транзакции на актуальных данных
+	result, err := s.taskRepo.TaskCompletion(ctx, taskProgress.UserID, taskProgress.TaskID, func(state *entity.TaskCompletionState) (bool, error) {
+		if !state.UserFound {
+			return false, newError(ErrNotFound, "Пользователь не найден")
+		}
+		// Проверка на существование задания
+		if state.Task == nil {
+			return false, newError(ErrNotFound, "Задание не найдено")
+		}
+		if state.Deleted {
+			return false, newError(ErrDeleted, "Задание или его квест удалены")
+		}
+		// Выполнять можно только задания опубликованных квестов, черновики игрокам не видны
+		switch state.QuestStatus {
+		case entity.QuestStatusDraft:
+			return false, newError(ErrNotFound, "Задание не найдено")
+		case entity.QuestStatusPaused:
+			return false, newError(ErrQuestInactive, "Квест приостановлен")
+		case entity.QuestStatusArchived:
+			return false, newError(ErrQuestInactive, "Квест в архиве")
+		}
+		if state.QuestNotStarted {
+			return false, newError(ErrOutsideWindow, "Квест ещё не начался")
+		}
+		if state.QuestEnded {
+			return false, newError(ErrOutsideWindow, "Квест уже завершился")
+		}
+		if state.QuestLocked {
+			return false, newError(ErrQuestLocked, "Сначала завершите квесты-условия")
+		}
+		if state.QuestSequential {
+			if previous := firstIncompleteBefore(state.TaskStatuses, state.Task.ID); previous != nil {
+				return false, newError(ErrOutOfOrder, "Сначала выполните задание «"+previous.Name+"»")
+			}
+		}
+		if dependency := firstIncompleteOf(state.TaskStatuses, state.DependsOn); dependency != nil {
+			return false, newError(ErrOutOfOrder, "Сначала выполните задание «"+dependency.Name+"»")
+		}
+		//	Есть ли уже записи о выполнении задания
+		if state.CompletedCount > 0 && !state.Task.IsReusable {
+			return false, newError(ErrAlreadyCompleted, "Вы уже выполнили это задание")
+		}
+		// Проверка на завершение квеста
+		return checkAllCompleted(state.TaskStatuses, state.Task.ID), nil
+	})
+	// Страховка на уровне БД от повторного выполнения
+	if repository.IsConstraint(err, "tasks_complete_user_task_once_idx") {
+		return nil, wrapError(ErrAlreadyCompleted, "Вы уже выполнили это задание", err)
 	}
-	// Проверка на завершение квеста
-	taskStatuses, err := s.taskRepo.GetTaskStatusesByQuestAndUser(taskInfo.QuestID, taskProgress.UserID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return result, nil
+}
+
+// GetTask возвращает задание, если его квест виден с правами visibility
+func (s *TaskService) GetTask(ctx context.Context, taskID int, visibility entity.QuestVisibility) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTask", tracing.TaskID(taskID))
+	defer span.End()
 
-	isLastTask := checkAllCompleted(taskStatuses, taskInfo.ID)
-	// Транзакция
-	err = s.taskRepo.TaskCompletion(taskProgress.UserID, taskProgress.TaskID, taskInfo.Cost, taskInfo.QuestID, isLastTask)
+	task, err := s.taskRepo.GetTaskByID(ctx, taskID, visibility)
 	if err != nil {
-		return err
+		return nil, translateNotFound(err, "Задание не найдено")
 	}
-
-	return nil
+	return task, nil
 }
 
-func (s *TaskService) CreateTask(task *entity.TaskInput) (int, error) {
-	return s.taskRepo.CreateTask(task)
+func (s *TaskService) CreateTask(ctx context.Context, task *entity.TaskInput) (int, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.CreateTask", tracing.QuestID(task.QuestID))
+	defer span.End()
+
+	taskID, err := s.taskRepo.CreateTask(ctx, task)
+	if errors.Is(err, repository.ErrParentDeleted) {
+		return 0, wrapError(ErrConflict, "Квест удалён, сначала восстановите квест", err)
+	}
+	if err != nil {
+		err = translateDependencyError(err)
+		return 0, translateNotFound(err, "Квест не найден")
+	}
+	return taskID, nil
 }
 
-func (s *TaskService) UpdateTask(taskID int, name string, cost int, isReusable bool) error {
+// UpdateTask обновляет задание, если его версия совпадает с version
+func (s *TaskService) UpdateTask(ctx context.Context, taskID, version int, patch *entity.TaskPatch) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.UpdateTask", tracing.TaskID(taskID))
+	defer span.End()
 
-	err := s.taskRepo.UpdateNameTask(taskID, name)
+	task, err := s.taskRepo.UpdateTask(ctx, taskID, version, patch)
 	if err != nil {
-		return err
+		err = translateDependencyError(err)
+		err = translateVersionMismatch(err, "Задание изменено другим запросом, получите актуальную версию")
+		return nil, translateNotFound(err, "Задание не найдено")
 	}
+	return task, nil
+}
 
-	err = s.taskRepo.UpdateCostTask(taskID, cost)
+// GetTaskGraph возвращает граф заданий квеста и отмечает задания, которые пользователь может выполнить сейчас
+func (s *TaskService) GetTaskGraph(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.TaskGraph, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.GetTaskGraph", tracing.UserID(userID), tracing.QuestID(questID))
+	defer span.End()
+
+	graph, err := s.taskRepo.GetTaskGraph(ctx, userID, questID, visibility)
 	if err != nil {
-		return err
+		return nil, translateNotFound(err, "Квест не найден")
+	}
+	if !graph.Open {
+		return graph, nil
+	}
+	// Те же правила, что и при выполнении задания
+	statuses := make([]entity.TaskStatus, 0, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		statuses = append(statuses, node.TaskStatus)
 	}
+	for i := range graph.Nodes {
+		node := &graph.Nodes[i]
+		done := node.IsCompleted && !node.IsReusable
+		blocked := graph.Sequential && firstIncompleteBefore(statuses, node.TaskID) != nil ||
+			firstIncompleteOf(statuses, node.DependsOn) != nil
+		node.Available = !done && !blocked
+	}
+	return graph, nil
+}
+
+// DeleteTask удаляет задание, если его версия совпадает с version
+func (s *TaskService) DeleteTask(ctx context.Context, taskID, version int) error {
+	ctx, span := tracing.Start(ctx, "TaskService.DeleteTask", tracing.TaskID(taskID))
+	defer span.End()
 
-	err = s.taskRepo.UpdateIsReusableTask(taskID, isReusable)
+	err := s.taskRepo.DeleteTask(ctx, taskID, version)
 	if err != nil {
-		return err
+		err = translateVersionMismatch(err, "Задание изменено другим запросом, получите актуальную версию")
+		return translateNotFound(err, "Задание не найдено")
 	}
-
 	return nil
-
 }
 
-func (s *TaskService) DeleteTask(taskID int) error {
-	return s.taskRepo.DeleteTask(taskID)
+// completionResult возвращает исход выполнения задания для метрик
+func completionResult(err error) string {
+	switch {
+	case err == nil:
+		return metrics.ResultOK
+	case errors.Is(err, ErrAlreadyCompleted):
+		return metrics.ResultAlreadyCompleted
+	case errors.Is(err, ErrNotFound):
+		return metrics.ResultNotFound
+	case errors.Is(err, ErrDeleted):
+		return metrics.ResultDeleted
+	case errors.Is(err, ErrOutsideWindow):
+		return metrics.ResultOutsideWindow
+	case errors.Is(err, ErrQuestInactive):
+		return metrics.ResultQuestInactive
+	case errors.Is(err, ErrQuestLocked):
+		return metrics.ResultQuestLocked
+	case errors.Is(err, ErrOutOfOrder):
+		return metrics.ResultOutOfOrder
+	default:
+		return metrics.ResultError
+	}
 }
 
 func checkAllCompleted(tasks []entity.TaskStatus, taskID int) bool {
@@ -90,3 +218,40 @@ func checkAllCompleted(tasks []entity.TaskStatus, taskID int) bool {
 	}
 	return true
 }
+
+// firstIncompleteBefore возвращает первое невыполненное задание, стоящее перед taskID.
+// tasks упорядочены по position
+func firstIncompleteBefore(tasks []entity.TaskStatus, taskID int) *entity.TaskStatus {
+	for i := range tasks {
+		if tasks[i].TaskID == taskID {
+			return nil
+		}
+		if !tasks[i].IsCompleted {
+			return &tasks[i]
+		}
+	}
+	return nil
+}
+
+// firstIncompleteOf возвращает первое невыполненное задание из ids
+func firstIncompleteOf(tasks []entity.TaskStatus, ids []int) *entity.TaskStatus {
+	for _, id := range ids {
+		for i := range tasks {
+			if tasks[i].TaskID == id && !tasks[i].IsCompleted {
+				return &tasks[i]
+			}
+		}
+	}
+	return nil
+}
+
+// translateDependencyError заменяет ошибки записи зависимостей заданий на ErrValidation
+func translateDependencyError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCycle), repository.IsConstraint(err, "task_dependencies_self_check"):
+		return wrapError(ErrValidation, "Зависимости заданий образуют цикл", err)
+	case errors.Is(err, repository.ErrReferenceMissing):
+		return wrapError(ErrValidation, "Задание из зависимостей не найдено в этом квесте", err)
+	}
+	return err
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"quest_service/internal/entity"
+	"quest_service/internal/metrics"
+	"quest_service/internal/repository"
+	"testing"
+)
+
+func TestCheckAllCompleted(t *testing.T) {
+	tests := []struct {
+		name   string
+		tasks  []entity.TaskStatus
+		taskID int
+		want   bool
+	}{
+		{
+			name:   "последнее задание квеста",
+			tasks:  []entity.TaskStatus{{TaskID: 1, IsCompleted: true}, {TaskID: 2}},
+			taskID: 2,
+			want:   true,
+		},
+		{
+			name:   "единственное задание",
+			tasks:  []entity.TaskStatus{{TaskID: 1}},
+			taskID: 1,
+			want:   true,
+		},
+		{
+			name:   "остались невыполненные задания",
+			tasks:  []entity.TaskStatus{{TaskID: 1}, {TaskID: 2}, {TaskID: 3, IsCompleted: true}},
+			taskID: 2,
+			want:   false,
+		},
+		{
+			// Повторное выполнение повторяемого задания не завершает квест второй раз
+			name:   "задание уже выполнено",
+			tasks:  []entity.TaskStatus{{TaskID: 1, IsCompleted: true}, {TaskID: 2, IsCompleted: true}},
+			taskID: 2,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkAllCompleted(tt.tasks, tt.taskID); got != tt.want {
+				t.Fatalf("checkAllCompleted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletionResult(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"успех", nil, metrics.ResultOK},
+		{"уже выполнено", newError(ErrAlreadyCompleted, "msg"), metrics.ResultAlreadyCompleted},
+		{"не найдено", newError(ErrNotFound, "msg"), metrics.ResultNotFound},
+		{"удалено", newError(ErrDeleted, "msg"), metrics.ResultDeleted},
+		{"вне окна", newError(ErrOutsideWindow, "msg"), metrics.ResultOutsideWindow},
+		{"квест неактивен", newError(ErrQuestInactive, "msg"), metrics.ResultQuestInactive},
+		{"квест заблокирован", newError(ErrQuestLocked, "msg"), metrics.ResultQuestLocked},
+		{"нарушен порядок", newError(ErrOutOfOrder, "msg"), metrics.ResultOutOfOrder},
+		{"обёрнутая ошибка сервиса", fmt.Errorf("tx: %w", newError(ErrNotFound, "msg")), metrics.ResultNotFound},
+		{"ошибка репозитория", repository.ErrUniqueViolation, metrics.ResultError},
+		{"таймаут", context.DeadlineExceeded, metrics.ResultError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completionResult(tt.err); got != tt.want {
+				t.Fatalf("completionResult(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstIncompleteBefore(t *testing.T) {
+	tasks := []entity.TaskStatus{
+		{TaskID: 10, Name: "first", IsCompleted: true},
+		{TaskID: 20, Name: "second"},
+		{TaskID: 30, Name: "third"},
+	}
+	tests := []struct {
+		name   string
+		taskID int
+		want   int
+	}{
+		{"первое задание", 10, 0},
+		{"предыдущие выполнены", 20, 0},
+		{"предыдущее не выполнено", 30, 20},
+		{"задания нет в квесте", 40, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := firstIncompleteBefore(tasks, tt.taskID)
+			if tt.want == 0 && got != nil || tt.want != 0 && (got == nil || got.TaskID != tt.want) {
+				t.Fatalf("firstIncompleteBefore(%d) = %+v, want task %d", tt.taskID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstIncompleteOf(t *testing.T) {
+	tasks := []entity.TaskStatus{
+		{TaskID: 10, IsCompleted: true},
+		{TaskID: 20},
+		{TaskID: 30},
+	}
+	tests := []struct {
+		name string
+		ids  []int
+		want int
+	}{
+		{"без зависимостей", nil, 0},
+		{"зависимости выполнены", []int{10}, 0},
+		{"первая невыполненная по списку", []int{10, 30, 20}, 30},
+		// Задания в корзине в статусах отсутствуют и выполнения не требуют
+		{"неизвестное задание", []int{40}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := firstIncompleteOf(tasks, tt.ids)
+			if tt.want == 0 && got != nil || tt.want != 0 && (got == nil || got.TaskID != tt.want) {
+				t.Fatalf("firstIncompleteOf(%v) = %+v, want task %d", tt.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+// completionTaskRepo вызывает decide с заданным состоянием и возвращает err вместо записи
+type completionTaskRepo struct {
+	repository.Task
+	state entity.TaskCompletionState
+	err   error
+}
+
+func (r *completionTaskRepo) TaskCompletion(_ context.Context, _, _ int, decide func(state *entity.TaskCompletionState) (bool, error)) (*entity.TaskCompletionResult, error) {
+	if _, err := decide(&r.state); err != nil {
+		return nil, err
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &entity.TaskCompletionResult{}, nil
+}
+
+func TestTaskCompletionErrors(t *testing.T) {
+	task := &entity.Task{ID: 1}
+	uniqueErr := &repository.ConstraintError{
+		Kind:       repository.ErrUniqueViolation,
+		Constraint: "tasks_complete_user_task_once_idx",
+		Err:        &pq.Error{Code: "23505"},
+	}
+	tests := []struct {
+		name string
+		repo *completionTaskRepo
+		want error
+	}{
+		{"пользователь не найден", &completionTaskRepo{}, ErrNotFound},
+		{"задание не найдено", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true}}, ErrNotFound},
+		{"задание удалено", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, Deleted: true}}, ErrDeleted},
+		{"черновик", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, QuestStatus: entity.QuestStatusDraft}}, ErrNotFound},
+		{"квест приостановлен", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, QuestStatus: entity.QuestStatusPaused}}, ErrQuestInactive},
+		{"квест в архиве", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, QuestStatus: entity.QuestStatusArchived}}, ErrQuestInactive},
+		{"квест не начался", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, QuestNotStarted: true}}, ErrOutsideWindow},
+		{"квест завершился", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, QuestEnded: true}}, ErrOutsideWindow},
+		{"квест заблокирован", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, QuestLocked: true}}, ErrQuestLocked},
+		{"нарушен порядок", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, QuestSequential: true,
+			TaskStatuses: []entity.TaskStatus{{TaskID: 2, Name: "first"}, {TaskID: 1, Name: "second"}}}}, ErrOutOfOrder},
+		{"зависимость не выполнена", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, DependsOn: []int{2},
+			TaskStatuses: []entity.TaskStatus{{TaskID: 1, Name: "first"}, {TaskID: 2, Name: "second"}}}}, ErrOutOfOrder},
+		{"уже выполнено", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task, CompletedCount: 1}}, ErrAlreadyCompleted},
+		{"уникальный индекс", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task}, err: uniqueErr}, ErrAlreadyCompleted},
+		{"успех", &completionTaskRepo{state: entity.TaskCompletionState{UserFound: true, Task: task}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTaskService(tt.repo).TaskCompletion(context.Background(), &entity.TaskProgress{UserID: 1, TaskID: 1})
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("TaskCompletion() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// graphTaskRepo — репозиторий заданий, возвращающий заданный граф
+type graphTaskRepo struct {
+	repository.Task
+	graph *entity.TaskGraph
+	err   error
+}
+
+func (r *graphTaskRepo) GetTaskGraph(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.TaskGraph, error) {
+	return r.graph, r.err
+}
+
+func graphNode(taskID int, completed, reusable bool, dependsOn ...int) entity.TaskGraphNode {
+	return entity.TaskGraphNode{
+		TaskStatus: entity.TaskStatus{TaskID: taskID, IsCompleted: completed, IsReusable: reusable},
+		DependsOn:  dependsOn,
+	}
+}
+
+func TestGetTaskGraphAvailable(t *testing.T) {
+	tests := []struct {
+		name       string
+		open       bool
+		sequential bool
+		nodes      []entity.TaskGraphNode
+		want       []bool
+	}{
+		{
+			name:  "квест закрыт",
+			open:  false,
+			nodes: []entity.TaskGraphNode{graphNode(1, false, false)},
+			want:  []bool{false},
+		},
+		{
+			name:  "выполненное задание недоступно, повторяемое доступно",
+			open:  true,
+			nodes: []entity.TaskGraphNode{graphNode(1, true, false), graphNode(2, true, true), graphNode(3, false, false)},
+			want:  []bool{false, true, true},
+		},
+		{
+			name:       "последовательный квест",
+			open:       true,
+			sequential: true,
+			nodes:      []entity.TaskGraphNode{graphNode(1, true, false), graphNode(2, false, false), graphNode(3, false, false)},
+			want:       []bool{false, true, false},
+		},
+		{
+			name:  "зависимости",
+			open:  true,
+			nodes: []entity.TaskGraphNode{graphNode(1, true, false), graphNode(2, false, false, 1), graphNode(3, false, false, 1, 2)},
+			want:  []bool{false, true, false},
+		},
+		{
+			name:       "зависимости в последовательном квесте",
+			open:       true,
+			sequential: true,
+			nodes:      []entity.TaskGraphNode{graphNode(1, false, false), graphNode(2, false, false, 3), graphNode(3, false, false)},
+			want:       []bool{true, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &graphTaskRepo{graph: &entity.TaskGraph{QuestID: 1, Open: tt.open, Sequential: tt.sequential, Nodes: tt.nodes}}
+			graph, err := NewTaskService(repo).GetTaskGraph(context.Background(), 1, 1, entity.QuestVisibility{})
+			if err != nil {
+				t.Fatalf("GetTaskGraph: %v", err)
+			}
+			for i, node := range graph.Nodes {
+				if node.Available != tt.want[i] {
+					t.Errorf("task %d available = %v, want %v", node.TaskID, node.Available, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetTaskGraphNotFound(t *testing.T) {
+	repo := &graphTaskRepo{err: repository.ErrNotFound}
+	_, err := NewTaskService(repo).GetTaskGraph(context.Background(), 1, 1, entity.QuestVisibility{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetTaskGraph() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTranslateDependencyError(t *testing.T) {
+	for _, err := range []error{repository.ErrCycle, repository.ErrReferenceMissing} {
+		if got := translateDependencyError(err); !errors.Is(got, ErrValidation) {
+			t.Errorf("translateDependencyError(%v) = %v, want ErrValidation", err, got)
+		}
+	}
+	if got := translateDependencyError(repository.ErrNotFound); got != repository.ErrNotFound {
+		t.Errorf("translateDependencyError(ErrNotFound) = %v, want unchanged", got)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"quest_service/internal/tracing"
+	"time"
+)
+
+// purgeBatchSize — сколько записей фоновая очистка выбирает за один запрос
+const purgeBatchSize = 100
+
+type TrashService struct {
+	trashRepo repository.Trash
+}
+
+func NewTrashService(trashRepo repository.Trash) *TrashService {
+	return &TrashService{trashRepo: trashRepo}
+}
+
+func (s *TrashService) GetDeletedQuests(ctx context.Context, page entity.Page) ([]entity.Quest, int, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.GetDeletedQuests")
+	defer span.End()
+
+	return s.trashRepo.GetDeletedQuests(ctx, page)
+}
+
+func (s *TrashService) GetDeletedTasks(ctx context.Context, page entity.Page) ([]entity.Task, int, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.GetDeletedTasks")
+	defer span.End()
+
+	return s.trashRepo.GetDeletedTasks(ctx, page)
+}
+
+func (s *TrashService) RestoreQuest(ctx context.Context, questID int) (*entity.Quest, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.RestoreQuest", tracing.QuestID(questID))
+	defer span.End()
+
+	quest, err := s.trashRepo.RestoreQuest(ctx, questID)
+	if err != nil {
+		return nil, translateNotFound(err, "Удалённый квест не найден")
+	}
+	return quest, nil
+}
+
+func (s *TrashService) RestoreTask(ctx context.Context, taskID int) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.RestoreTask", tracing.TaskID(taskID))
+	defer span.End()
+
+	task, err := s.trashRepo.RestoreTask(ctx, taskID)
+	if errors.Is(err, repository.ErrParentDeleted) {
+		return nil, wrapError(ErrConflict, "Квест задания удалён, сначала восстановите квест", err)
+	}
+	if err != nil {
+		return nil, translateNotFound(err, "Удалённое задание не найдено")
+	}
+	return task, nil
+}
+
+// PurgeQuest окончательно удаляет квест из корзины, если его версия совпадает с version
+func (s *TrashService) PurgeQuest(ctx context.Context, questID, version int) (*entity.PurgeResult, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.PurgeQuest", tracing.QuestID(questID))
+	defer span.End()
+
+	result, err := s.trashRepo.PurgeQuest(ctx, questID, version)
+	if err != nil {
+		err = translateVersionMismatch(err, "Квест изменён другим запросом, получите актуальную версию")
+		return nil, translateNotFound(err, "Удалённый квест не найден")
+	}
+	return result, nil
+}
+
+// PurgeTask окончательно удаляет задание из корзины, если его версия совпадает с version
+func (s *TrashService) PurgeTask(ctx context.Context, taskID, version int) (*entity.PurgeResult, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.PurgeTask", tracing.TaskID(taskID))
+	defer span.End()
+
+	result, err := s.trashRepo.PurgeTask(ctx, taskID, version)
+	if err != nil {
+		err = translateVersionMismatch(err, "Задание изменено другим запросом, получите актуальную версию")
+		return nil, translateNotFound(err, "Удалённое задание не найдено")
+	}
+	return result, nil
+}
+
+// PurgeExpired очищает корзину от квестов и заданий, удалённых раньше before.
+// Каждая запись удаляется в своей транзакции, поэтому прерванная очистка сохраняет сделанное
+func (s *TrashService) PurgeExpired(ctx context.Context, before time.Time) (entity.PurgeStats, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.PurgeExpired")
+	defer span.End()
+
+	var stats entity.PurgeStats
+	// Сначала квесты: вместе с ними уходят и их задания
+	err := purgeBatches(ctx, func() ([]int, error) {
+		return s.trashRepo.GetExpiredQuestIDs(ctx, before, purgeBatchSize)
+	}, func(id int) (*entity.PurgeResult, error) {
+		return s.trashRepo.PurgeQuest(ctx, id, entity.AnyVersion)
+	}, &stats.Quests, &stats.Anonymized)
+	if err != nil {
+		return stats, err
+	}
+	err = purgeBatches(ctx, func() ([]int, error) {
+		return s.trashRepo.GetExpiredTaskIDs(ctx, before, purgeBatchSize)
+	}, func(id int) (*entity.PurgeResult, error) {
+		return s.trashRepo.PurgeTask(ctx, id, entity.AnyVersion)
+	}, &stats.Tasks, &stats.Anonymized)
+	return stats, err
+}
+
+// purgeBatches очищает записи пачками, пока они не закончатся
+func purgeBatches(ctx context.Context, next func() ([]int, error), purge func(id int) (*entity.PurgeResult, error), deleted, anonymized *int) error {
+	for {
+		ids, err := next()
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			result, err := purge(id)
+			// Запись могли восстановить или удалить вместе с квестом после выборки
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if result.Outcome == entity.PurgeAnonymized {
+				*anonymized++
+			} else {
+				*deleted++
+			}
+		}
+		if len(ids) < purgeBatchSize || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
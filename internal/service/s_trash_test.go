@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"quest_service/internal/entity"
+	"quest_service/internal/repository"
+	"testing"
+)
+
+func TestPurgeBatches(t *testing.T) {
+	// Три пачки: полная, полная и неполная
+	batches := [][]int{make([]int, purgeBatchSize), make([]int, purgeBatchSize), {1, 2, 3}}
+	for i := range batches[0] {
+		batches[0][i] = i + 10
+		batches[1][i] = i + 10 + purgeBatchSize
+	}
+	calls := 0
+	next := func() ([]int, error) {
+		batch := batches[calls]
+		calls++
+		return batch, nil
+	}
+	purge := func(id int) (*entity.PurgeResult, error) {
+		switch {
+		case id == 1:
+			// Запись восстановили после выборки
+			return nil, repository.ErrNotFound
+		case id%2 == 0:
+			return &entity.PurgeResult{ID: id, Outcome: entity.PurgeAnonymized}, nil
+		default:
+			return &entity.PurgeResult{ID: id, Outcome: entity.PurgeDeleted}, nil
+		}
+	}
+
+	var deleted, anonymized int
+	if err := purgeBatches(context.Background(), next, purge, &deleted, &anonymized); err != nil {
+		t.Fatalf("purgeBatches: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("batches fetched = %d, want 3", calls)
+	}
+	// 2*purgeBatchSize + 2 записи: половина обезличена, одна пропущена
+	if deleted != purgeBatchSize+1 || anonymized != purgeBatchSize+1 {
+		t.Fatalf("deleted = %d, anonymized = %d, want %d and %d", deleted, anonymized, purgeBatchSize+1, purgeBatchSize+1)
+	}
+}
+
+func TestPurgeBatchesStops(t *testing.T) {
+	boom := errors.New("boom")
+	full := make([]int, purgeBatchSize)
+	var deleted, anonymized int
+
+	err := purgeBatches(context.Background(), func() ([]int, error) { return nil, boom }, nil, &deleted, &anonymized)
+	if !errors.Is(err, boom) {
+		t.Fatalf("purgeBatches(next error) = %v, want %v", err, boom)
+	}
+	err = purgeBatches(context.Background(), func() ([]int, error) { return full, nil }, func(int) (*entity.PurgeResult, error) {
+		return nil, boom
+	}, &deleted, &anonymized)
+	if !errors.Is(err, boom) {
+		t.Fatalf("purgeBatches(purge error) = %v, want %v", err, boom)
+	}
+
+	// Отменённый контекст прерывает очистку после текущей пачки
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err = purgeBatches(ctx, func() ([]int, error) {
+		calls++
+		cancel()
+		return full, nil
+	}, func(id int) (*entity.PurgeResult, error) {
+		return &entity.PurgeResult{ID: id, Outcome: entity.PurgeDeleted}, nil
+	}, &deleted, &anonymized)
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Fatalf("purgeBatches(canceled) = %v after %d batches, want context.Canceled after 1", err, calls)
+	}
+}
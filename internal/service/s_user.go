@@ -1,33 +1,85 @@
 package service
 
 import (
+	"context"
 	"quest_service/internal/entity"
 	"quest_service/internal/repository"
+	"quest_service/internal/tracing"
 )
 
 type UserService struct {
-	userRepo repository.User
+	userRepo   repository.User
+	ledgerRepo repository.Ledger
 }
 
-func NewUserService(userRepo repository.User) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo repository.User, ledgerRepo repository.Ledger) *UserService {
+	return &UserService{userRepo: userRepo, ledgerRepo: ledgerRepo}
 }
 
-func (s *UserService) CreateUser(user *entity.UserInput) (int, error) {
-	return s.userRepo.CreateUser(user)
+func (s *UserService) CreateUser(ctx context.Context, user *entity.UserInput) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
+	userID, err := s.userRepo.CreateUser(ctx, user)
+	if repository.IsConstraint(err, "users_username_key") {
+		return 0, wrapError(ErrConflict, "Пользователь с таким именем уже существует", err)
+	}
+	return userID, err
 }
 
-func (s *UserService) GetBalanceAndHistoryTasks(userID int) (int, []entity.Task, error) {
+func (s *UserService) GetUser(ctx context.Context, userID int) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUser", tracing.UserID(userID))
+	defer span.End()
 
-	balance, err := s.userRepo.GetUserBalance(userID)
+	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
-		return 0, nil, err
+		return nil, translateNotFound(err, "Пользователь не найден")
+	}
+	return user, nil
+}
+
+func (s *UserService) GetBalanceAndHistoryTasks(ctx context.Context, userID int) (int, []entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetBalanceAndHistoryTasks", tracing.UserID(userID))
+	defer span.End()
+
+	balance, err := s.userRepo.GetUserBalance(ctx, userID)
+	if err != nil {
+		return 0, nil, translateNotFound(err, "Пользователь не найден")
 	}
 
-	tasks, err := s.userRepo.GetUserTasksHistoryByUserID(userID)
+	tasks, err := s.userRepo.GetUserTasksHistoryByUserID(ctx, userID)
 	if err != nil {
 		return 0, nil, err
 	}
 
 	return balance, tasks, nil
 }
+
+func (s *UserService) GetTransactions(ctx context.Context, userID int, page entity.Page) ([]entity.LedgerEntry, int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetTransactions", tracing.UserID(userID))
+	defer span.End()
+
+	return s.ledgerRepo.GetEntriesByUserID(ctx, userID, page)
+}
+
+func (s *UserService) CreateTransaction(ctx context.Context, input *entity.LedgerEntryInput) (*entity.LedgerEntry, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateTransaction", tracing.UserID(input.UserID))
+	defer span.End()
+
+	entry, err := s.ledgerRepo.CreateEntry(ctx, input)
+	if repository.IsConstraint(err, "users_balance_check") {
+		return nil, wrapError(ErrConflict, "Недостаточно средств", err)
+	}
+	if err != nil {
+		return nil, translateNotFound(err, "Пользователь не найден")
+	}
+	return entry, nil
+}
+
+func (s *UserService) UpdateRole(ctx context.Context, userID int, role string) error {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateRole", tracing.UserID(userID))
+	defer span.End()
+
+	err := s.userRepo.UpdateUserRole(ctx, userID, role)
+	return translateNotFound(err, "Пользователь не найден")
+}
@@ -1,40 +1,84 @@
 package service
 
 import (
+	"context"
+	"quest_service/configs"
 	"quest_service/internal/entity"
 	"quest_service/internal/repository"
+	"time"
 )
 
+type Authorization interface {
+	SignUp(ctx context.Context, input *entity.SignUpInput) (int, error)
+	SignIn(ctx context.Context, input *entity.SignInInput) (*entity.Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*entity.Tokens, error)
+	ParseToken(ctx context.Context, accessToken string) (*entity.Identity, error)
+}
+
 type User interface {
-	CreateUser(user *entity.UserInput) (int, error)
-	GetBalanceAndHistoryTasks(userID int) (int, []entity.Task, error)
+	CreateUser(ctx context.Context, user *entity.UserInput) (int, error)
+	GetUser(ctx context.Context, userID int) (*entity.User, error)
+	GetBalanceAndHistoryTasks(ctx context.Context, userID int) (int, []entity.Task, error)
+	GetTransactions(ctx context.Context, userID int, page entity.Page) ([]entity.LedgerEntry, int, error)
+	CreateTransaction(ctx context.Context, input *entity.LedgerEntryInput) (*entity.LedgerEntry, error)
+	UpdateRole(ctx context.Context, userID int, role string) error
 }
 
 type Quest interface {
-	CreateQuest(quest *entity.QuestInput) (int, error)
-	GetQuestsAndTasks() ([]entity.Quest, error)
-	UpdateQuest(questID int, quest *entity.QuestInput) error
-	DeleteQuest(questID int) error
-	CreateTestQuestData() error
+	CreateQuest(ctx context.Context, quest *entity.QuestInput) (int, error)
+	GetQuests(ctx context.Context, filter *entity.QuestFilter) (*entity.QuestPage, error)
+	GetQuest(ctx context.Context, questID int, visibility entity.QuestVisibility) (*entity.Quest, error)
+	GetQuestsProgress(ctx context.Context, userID int, page entity.Page, visibility entity.QuestVisibility) ([]entity.QuestProgress, int, error)
+	GetQuestProgress(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.QuestProgress, error)
+	UpdateQuest(ctx context.Context, questID, version int, patch *entity.QuestPatch) (*entity.Quest, error)
+	ChangeQuestStatus(ctx context.Context, questID, version int, status string) (*entity.Quest, error)
+	GetPrerequisites(ctx context.Context, questID int, visibility entity.QuestVisibility) (*entity.QuestPrerequisites, error)
+	SetPrerequisites(ctx context.Context, questID, version int, input *entity.QuestPrerequisitesInput) (*entity.QuestPrerequisites, error)
+	ReorderTasks(ctx context.Context, questID, version int, input *entity.TaskOrderInput) (*entity.Quest, error)
+	DeleteQuest(ctx context.Context, questID, version int) error
+	CreateTestQuestData(ctx context.Context) error
 }
 
 type Task interface {
-	TaskCompletion(taskProgress *entity.TaskProgress) error
-	CreateTask(task *entity.TaskInput) (int, error)
-	UpdateTask(taskID int, name string, cost int, isReusable bool) error
-	DeleteTask(taskID int) error
+	TaskCompletion(ctx context.Context, taskProgress *entity.TaskProgress) (*entity.TaskCompletionResult, error)
+	GetTask(ctx context.Context, taskID int, visibility entity.QuestVisibility) (*entity.Task, error)
+	CreateTask(ctx context.Context, task *entity.TaskInput) (int, error)
+	UpdateTask(ctx context.Context, taskID, version int, patch *entity.TaskPatch) (*entity.Task, error)
+	DeleteTask(ctx context.Context, taskID, version int) error
+	GetTaskGraph(ctx context.Context, userID, questID int, visibility entity.QuestVisibility) (*entity.TaskGraph, error)
+}
+
+type Trash interface {
+	GetDeletedQuests(ctx context.Context, page entity.Page) ([]entity.Quest, int, error)
+	GetDeletedTasks(ctx context.Context, page entity.Page) ([]entity.Task, int, error)
+	RestoreQuest(ctx context.Context, questID int) (*entity.Quest, error)
+	RestoreTask(ctx context.Context, taskID int) (*entity.Task, error)
+	PurgeQuest(ctx context.Context, questID, version int) (*entity.PurgeResult, error)
+	PurgeTask(ctx context.Context, taskID, version int) (*entity.PurgeResult, error)
+	PurgeExpired(ctx context.Context, before time.Time) (entity.PurgeStats, error)
+}
+
+type Health interface {
+	Ready(ctx context.Context) *entity.Readiness
+	StartDraining()
 }
 
 type Service struct {
+	Authorization
 	User
 	Quest
 	Task
+	Trash
+	Health
 }
 
-func NewService(repos *repository.Repository) *Service {
+func NewService(repos *repository.Repository, cfg configs.Config) *Service {
 	return &Service{
-		User:  NewUserService(repos.User),
-		Quest: NewQuestService(repos.Quest),
-		Task:  NewTaskService(repos.Task),
+		Authorization: NewAuthService(repos.User, cfg),
+		User:          NewUserService(repos.User, repos.Ledger),
+		Quest:         NewQuestService(repos.Quest, repos.Task),
+		Task:          NewTaskService(repos.Task),
+		Trash:         NewTrashService(repos.Trash),
+		Health:        NewHealthService(repos.Health),
 	}
 }
@@ -0,0 +1,22 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetupExporters(t *testing.T) {
+	ctx := context.Background()
+	for _, exporter := range []string{ExporterNone, ExporterStdout} {
+		shutdown, err := Setup(ctx, exporter, 1)
+		if err != nil {
+			t.Fatalf("Setup(%q): %v", exporter, err)
+		}
+		if err = shutdown(ctx); err != nil {
+			t.Fatalf("shutdown(%q): %v", exporter, err)
+		}
+	}
+	if _, err := Setup(ctx, "jaeger", 1); err == nil {
+		t.Fatal("Setup(jaeger) error = nil, want error")
+	}
+}
@@ -0,0 +1,5 @@
+ALTER TABLE quests_complete DROP CONSTRAINT quests_complete_user_quest_key;
+
+DROP INDEX tasks_complete_user_task_once_idx;
+
+ALTER TABLE tasks_complete DROP COLUMN is_reusable;
@@ -0,0 +1,19 @@
+-- Признак повторяемости фиксируется в момент выполнения задания,
+-- чтобы уникальность неповторяемых выполнений обеспечивалась самой БД
+ALTER TABLE tasks_complete ADD COLUMN is_reusable BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE tasks_complete tc SET is_reusable = t.is_reusable FROM tasks t WHERE t.id = tc.task_id;
+
+-- Удаляем дубликаты, оставшиеся после одновременных запросов
+DELETE FROM tasks_complete a USING tasks_complete b
+WHERE NOT a.is_reusable AND NOT b.is_reusable
+  AND a.user_id = b.user_id AND a.task_id = b.task_id
+  AND (a.completed_at, a.ctid) > (b.completed_at, b.ctid);
+
+DELETE FROM quests_complete a USING quests_complete b
+WHERE a.user_id = b.user_id AND a.quest_id = b.quest_id
+  AND (a.completed_at, a.ctid) > (b.completed_at, b.ctid);
+
+CREATE UNIQUE INDEX tasks_complete_user_task_once_idx ON tasks_complete (user_id, task_id) WHERE NOT is_reusable;
+
+ALTER TABLE quests_complete ADD CONSTRAINT quests_complete_user_quest_key UNIQUE (user_id, quest_id);
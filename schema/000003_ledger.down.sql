@@ -0,0 +1,3 @@
+ALTER TABLE users ALTER COLUMN balance DROP NOT NULL;
+
+DROP TABLE ledger_entries;
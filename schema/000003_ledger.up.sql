@@ -0,0 +1,20 @@
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount <> 0),
+    balance_after INTEGER NOT NULL CHECK (balance_after >= 0),
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('task', 'quest_bonus', 'admin', 'purchase')),
+    source_id INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX ledger_entries_user_id_idx ON ledger_entries (user_id, id DESC);
+
+UPDATE users SET balance = 0 WHERE balance IS NULL;
+
+ALTER TABLE users ALTER COLUMN balance SET NOT NULL;
+
+-- Входящие остатки: текущий баланс переносится в журнал одной корректировкой
+INSERT INTO ledger_entries (user_id, amount, balance_after, reason)
+SELECT id, balance, balance, 'admin' FROM users WHERE balance <> 0;
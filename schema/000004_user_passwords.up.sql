@@ -0,0 +1,2 @@
+-- Пользователи, созданные до появления регистрации, остаются без пароля и не могут войти
+ALTER TABLE users ADD COLUMN password_hash VARCHAR(255);
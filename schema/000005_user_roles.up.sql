@@ -0,0 +1,2 @@
+-- Первого администратора назначают вручную: UPDATE users SET role = 'admin' WHERE username = '...';
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'player' CHECK (role IN ('admin', 'editor', 'player'));
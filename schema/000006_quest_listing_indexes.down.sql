@@ -0,0 +1,8 @@
+DROP INDEX tasks_quest_id_idx;
+
+DROP INDEX quests_name_id_idx;
+DROP INDEX quests_created_at_id_idx;
+DROP INDEX quests_cost_id_idx;
+
+ALTER TABLE quests ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE quests ALTER COLUMN cost DROP NOT NULL;
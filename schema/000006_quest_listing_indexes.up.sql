@@ -0,0 +1,12 @@
+-- Курсорная пагинация требует NOT NULL у полей сортировки
+UPDATE quests SET cost = 0 WHERE cost IS NULL;
+UPDATE quests SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+
+ALTER TABLE quests ALTER COLUMN cost SET NOT NULL;
+ALTER TABLE quests ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX quests_cost_id_idx ON quests (cost, id) WHERE deleted_at IS NULL;
+CREATE INDEX quests_created_at_id_idx ON quests (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX quests_name_id_idx ON quests (name, id) WHERE deleted_at IS NULL;
+
+CREATE INDEX tasks_quest_id_idx ON tasks (quest_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- Версия строки для оптимистической блокировки: увеличивается при каждом изменении
+ALTER TABLE quests ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
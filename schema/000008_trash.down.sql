@@ -0,0 +1,8 @@
+DROP INDEX quests_complete_quest_id_idx;
+DROP INDEX tasks_complete_task_id_idx;
+
+DROP INDEX tasks_trash_idx;
+DROP INDEX quests_trash_idx;
+
+ALTER TABLE tasks DROP COLUMN purged_at;
+ALTER TABLE quests DROP COLUMN purged_at;
@@ -0,0 +1,11 @@
+-- Удалённая запись, на которую ссылается история выполнений, не стирается, а обезличивается.
+-- purged_at — время обезличивания, такие записи не показываются в корзине
+ALTER TABLE quests ADD COLUMN purged_at TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN purged_at TIMESTAMP;
+
+CREATE INDEX quests_trash_idx ON quests (deleted_at) WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+CREATE INDEX tasks_trash_idx ON tasks (deleted_at) WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+
+-- Проверка ссылок из истории перед окончательным удалением
+CREATE INDEX tasks_complete_task_id_idx ON tasks_complete (task_id);
+CREATE INDEX quests_complete_quest_id_idx ON quests_complete (quest_id);
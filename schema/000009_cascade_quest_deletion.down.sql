@@ -0,0 +1,5 @@
+-- Задания удалённых квестов снова живые, как до каскадного удаления. Задание, удалённое отдельно
+-- раньше квеста, имеет другую отметку времени и остаётся удалённым
+UPDATE tasks t SET deleted_at = NULL, version = t.version + 1
+FROM quests q
+WHERE q.id = t.quest_id AND q.deleted_at IS NOT NULL AND t.deleted_at = q.deleted_at;
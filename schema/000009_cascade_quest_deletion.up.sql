@@ -0,0 +1,5 @@
+-- Задания удалённых квестов удаляются вместе с квестом и с той же отметкой времени,
+-- по ней восстановление квеста возвращает и его задания
+UPDATE tasks t SET deleted_at = q.deleted_at, version = t.version + 1
+FROM quests q
+WHERE q.id = t.quest_id AND q.deleted_at IS NOT NULL AND t.deleted_at IS NULL;
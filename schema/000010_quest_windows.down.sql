@@ -0,0 +1,3 @@
+ALTER TABLE quests DROP CONSTRAINT quests_window_check;
+ALTER TABLE quests DROP COLUMN ends_at;
+ALTER TABLE quests DROP COLUMN starts_at;
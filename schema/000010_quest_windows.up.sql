@@ -0,0 +1,4 @@
+-- Окно доступности квеста: пустая граница не ограничивает
+ALTER TABLE quests ADD COLUMN starts_at TIMESTAMPTZ;
+ALTER TABLE quests ADD COLUMN ends_at TIMESTAMPTZ;
+ALTER TABLE quests ADD CONSTRAINT quests_window_check CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at);
@@ -0,0 +1,5 @@
+-- Жизненный цикл квеста. Существующие квесты уже видны игрокам, поэтому они опубликованы,
+-- новые создаются черновиками
+ALTER TABLE quests ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE quests ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE quests ADD CONSTRAINT quests_status_check CHECK (status IN ('draft', 'published', 'paused', 'archived'));
@@ -0,0 +1,12 @@
+-- Цепочки квестов: квест открывается после завершения всех его квестов-условий.
+-- Отсутствие циклов проверяется при записи
+CREATE TABLE quest_prerequisites (
+    quest_id INTEGER NOT NULL,
+    prerequisite_id INTEGER NOT NULL,
+    PRIMARY KEY (quest_id, prerequisite_id),
+    CONSTRAINT quest_prerequisites_self_check CHECK (quest_id <> prerequisite_id),
+    FOREIGN KEY (quest_id) REFERENCES quests(id) ON DELETE CASCADE,
+    FOREIGN KEY (prerequisite_id) REFERENCES quests(id) ON DELETE CASCADE
+);
+
+CREATE INDEX quest_prerequisites_prerequisite_id_idx ON quest_prerequisites (prerequisite_id);
@@ -0,0 +1,2 @@
+ALTER TABLE quests DROP COLUMN sequential;
+ALTER TABLE tasks DROP COLUMN position;
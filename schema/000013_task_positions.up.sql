@@ -0,0 +1,9 @@
+-- Порядок заданий в квесте. Существующие задания нумеруются в порядке создания
+ALTER TABLE tasks ADD COLUMN position INTEGER;
+UPDATE tasks t SET position = n.position
+FROM (SELECT id, row_number() OVER (PARTITION BY quest_id ORDER BY id) AS position FROM tasks) n
+WHERE t.id = n.id;
+ALTER TABLE tasks ALTER COLUMN position SET NOT NULL;
+
+-- В последовательном квесте задание выполняется после всех предыдущих
+ALTER TABLE quests ADD COLUMN sequential BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,12 @@
+-- Зависимости заданий внутри квеста: задание выполняется после всех заданий, от которых зависит.
+-- Принадлежность одному квесту и отсутствие циклов проверяются при записи
+CREATE TABLE task_dependencies (
+    task_id INTEGER NOT NULL,
+    depends_on_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, depends_on_id),
+    CONSTRAINT task_dependencies_self_check CHECK (task_id <> depends_on_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (depends_on_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX task_dependencies_depends_on_id_idx ON task_dependencies (depends_on_id);
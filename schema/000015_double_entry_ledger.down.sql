@@ -0,0 +1,10 @@
+DROP TRIGGER ledger_entries_balanced ON ledger_entries;
+DROP FUNCTION ledger_transaction_balanced();
+
+DELETE FROM ledger_entries WHERE account <> 'user';
+
+ALTER TABLE ledger_entries
+    DROP COLUMN transaction_id,
+    DROP COLUMN account,
+    ALTER COLUMN user_id SET NOT NULL,
+    ALTER COLUMN balance_after SET NOT NULL;
@@ -0,0 +1,48 @@
+-- Двойная запись: каждое движение по балансу пользователя уравновешивается проводкой
+-- на системном счёте. Выплаты за задания и корректировки идут со счёта payouts,
+-- покупки — на счёт purchases. Проводки одной операции связаны transaction_id, их сумма равна нулю
+CREATE SEQUENCE ledger_transaction_id_seq;
+
+ALTER TABLE ledger_entries
+    ADD COLUMN account VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (account IN ('user', 'payouts', 'purchases')),
+    ADD COLUMN transaction_id INTEGER;
+
+UPDATE ledger_entries SET transaction_id = id;
+
+SELECT setval('ledger_transaction_id_seq', COALESCE(max(transaction_id), 0) + 1, false) FROM ledger_entries;
+
+ALTER TABLE ledger_entries
+    ALTER COLUMN transaction_id SET DEFAULT nextval('ledger_transaction_id_seq'),
+    ALTER COLUMN transaction_id SET NOT NULL,
+    ALTER COLUMN user_id DROP NOT NULL,
+    ALTER COLUMN balance_after DROP NOT NULL;
+
+ALTER SEQUENCE ledger_transaction_id_seq OWNED BY ledger_entries.transaction_id;
+
+-- Пользователь и остаток есть только у проводок по счёту пользователя
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_account_owner_check
+    CHECK ((account = 'user') = (user_id IS NOT NULL AND balance_after IS NOT NULL));
+
+-- Встречные проводки для накопленного журнала
+INSERT INTO ledger_entries (account, amount, reason, source_id, created_at, transaction_id)
+SELECT CASE WHEN reason = 'purchase' THEN 'purchases' ELSE 'payouts' END,
+       -amount, reason, source_id, created_at, transaction_id
+FROM ledger_entries;
+
+CREATE INDEX ledger_entries_transaction_id_idx ON ledger_entries (transaction_id);
+
+-- Сумма проводок операции проверяется при фиксации транзакции, когда записаны обе стороны
+CREATE FUNCTION ledger_transaction_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT sum(amount) FROM ledger_entries WHERE transaction_id = NEW.transaction_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger transaction % is unbalanced', NEW.transaction_id
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'ledger_entries_balanced';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT OR UPDATE ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_transaction_balanced();
@@ -0,0 +1,9 @@
+// Package schema содержит SQL-миграции, встроенные в бинарный файл
+package schema
+
+import "embed"
+
+// Migrations — файлы вида 000001_name.up.sql и 000001_name.down.sql
+//
+//go:embed *.sql
+var Migrations embed.FS